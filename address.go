@@ -0,0 +1,62 @@
+package vaultkv
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+//transportKey holds the settings of a Client that its built HTTP client
+// depends on, so that it can be rebuilt when they change.
+type transportKey struct {
+	tls    *TLSConfig
+	proxy  *url.URL
+	socket string
+}
+
+func (v *Client) transportKey() transportKey {
+	ret := transportKey{tls: v.TLS, proxy: v.Proxy}
+	if v.VaultURL != nil && isUnixSocket(v.VaultURL) {
+		ret.socket = v.VaultURL.Path
+	}
+
+	return ret
+}
+
+func isUnixSocket(u *url.URL) bool {
+	return u.Scheme == "unix"
+}
+
+//Transport returns a new *http.Transport with the same defaults as
+// http.DefaultTransport, but which uses the TLS and Proxy settings of the
+// Client, and which connects to the unix socket given as the VaultURL, if it
+// is one. This is useful for building an HTTP client with custom settings to
+// use as the Client member.
+func (v *Client) Transport() (*http.Transport, error) {
+	var ret *http.Transport
+	if v.TLS != nil {
+		var err error
+		ret, err = v.TLS.Transport()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ret = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	if v.Proxy != nil {
+		ret.Proxy = http.ProxyURL(v.Proxy)
+	}
+
+	if v.VaultURL != nil && isUnixSocket(v.VaultURL) {
+		socket := v.VaultURL.Path
+		dialer := &net.Dialer{}
+		ret.Proxy = nil
+		ret.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+	}
+
+	return ret, nil
+}
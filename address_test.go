@@ -0,0 +1,120 @@
+package vaultkv_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Addressing", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var requested *url.URL
+	var requestedHost string
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested, requestedHost = r.URL, r.Host
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+	})
+
+	BeforeEach(func() {
+		requested, requestedHost = nil, ""
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("the VaultURL is a unix socket", func() {
+		var socketDir string
+
+		BeforeEach(func() {
+			socketDir, err = ioutil.TempDir("", "vaultkv-test-socket")
+			Expect(err).NotTo(HaveOccurred())
+			socketPath := filepath.Join(socketDir, "agent.sock")
+
+			server = httptest.NewUnstartedServer(handler)
+			server.Listener, err = net.Listen("unix", socketPath)
+			Expect(err).NotTo(HaveOccurred())
+			server.Start()
+
+			client = &vaultkv.Client{
+				VaultURL: &url.URL{Scheme: "unix", Path: socketPath},
+				Trace:    GinkgoWriter,
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(socketDir)
+		})
+
+		It("should make requests over the socket", func() {
+			output := map[string]string{}
+			err = client.Get("secret/foo", &output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(requested.Path).To(Equal("/v1/secret/foo"))
+			Expect(requestedHost).To(Equal("localhost"))
+		})
+	})
+
+	When("a proxy is configured", func() {
+		BeforeEach(func() {
+			//The test server acts as the proxy, and so receives the full URL of
+			// each request
+			server = httptest.NewServer(handler)
+			proxyURL, err := url.Parse(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+
+			client = &vaultkv.Client{
+				VaultURL: &url.URL{Scheme: "http", Host: "vault.example.com"},
+				Proxy:    proxyURL,
+				Trace:    GinkgoWriter,
+			}
+		})
+
+		It("should send requests through the proxy, to port 8200", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requested.String()).To(Equal("http://vault.example.com:8200/v1/secret/foo"))
+		})
+
+		When("SchemeDefaultPort is set", func() {
+			BeforeEach(func() {
+				client.SchemeDefaultPort = true
+			})
+
+			It("should not add port 8200", func() {
+				err = client.Get("secret/foo", nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(requested.String()).To(Equal("http://vault.example.com/v1/secret/foo"))
+			})
+		})
+	})
+
+	Describe("NewClient", func() {
+		BeforeEach(func() {
+			server = httptest.NewServer(handler)
+			client, err = vaultkv.NewClient(vaultkv.Config{
+				Address:      "http://vault.example.com",
+				ProxyAddress: server.URL,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should use the proxy and the default port of the scheme", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requested.String()).To(Equal("http://vault.example.com/v1/secret/foo"))
+		})
+	})
+})
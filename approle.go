@@ -0,0 +1,490 @@
+package vaultkv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//ApproleRole is the configuration of a role of the approle auth method, which
+// constrains the secret IDs generated for it, and gives the properties of the
+// tokens issued when logging in with them.
+type ApproleRole struct {
+	//BindSecretID requires a secret ID to be given when logging in as the role.
+	// Vault makes roles require one by default, but as every member is written
+	// by ApproleRoleWrite, it must be set here for a role that requires one.
+	BindSecretID bool
+	//SecretIDBoundCIDRs are the CIDR blocks from which secret IDs of the role
+	// may be used to log in. If empty, they may be used from anywhere.
+	SecretIDBoundCIDRs []string
+	//SecretIDNumUses is the number of times a secret ID of the role may be used
+	// to log in before it expires. Zero means unlimited.
+	SecretIDNumUses int
+	//SecretIDTTL is the time after which secret IDs of the role expire. Zero
+	// means they do not.
+	SecretIDTTL time.Duration
+	//LocalSecretIDs makes the secret IDs of the role local to the cluster, such
+	// that they are not replicated. Vault only allows it to be set when the
+	// role is created, so it is only sent when true.
+	LocalSecretIDs bool
+	//TokenPolicies are the policies of the tokens issued to the role.
+	TokenPolicies []string
+	TokenTTL      time.Duration
+	TokenMaxTTL   time.Duration
+	//TokenExplicitMaxTTL is a TTL past which the tokens issued to the role
+	// cannot be renewed, regardless of the max TTLs of the system and mount.
+	TokenExplicitMaxTTL time.Duration
+	//TokenPeriod makes the tokens issued to the role periodic, such that each
+	// renewal gives them this TTL, and they have no max TTL.
+	TokenPeriod time.Duration
+	//TokenBoundCIDRs are the CIDR blocks from which the tokens issued to the
+	// role may be used.
+	TokenBoundCIDRs      []string
+	TokenNoDefaultPolicy bool
+	TokenNumUses         int
+	//TokenType is "service", "batch", "default-service" or "default-batch".
+	TokenType string
+}
+
+type approleRoleAPI struct {
+	BindSecretID         bool     `json:"bind_secret_id"`
+	SecretIDBoundCIDRs   []string `json:"secret_id_bound_cidrs"`
+	SecretIDNumUses      int      `json:"secret_id_num_uses"`
+	SecretIDTTL          int64    `json:"secret_id_ttl"`
+	LocalSecretIDs       bool     `json:"local_secret_ids,omitempty"`
+	TokenPolicies        []string `json:"token_policies"`
+	TokenTTL             int64    `json:"token_ttl"`
+	TokenMaxTTL          int64    `json:"token_max_ttl"`
+	TokenExplicitMaxTTL  int64    `json:"token_explicit_max_ttl"`
+	TokenPeriod          int64    `json:"token_period"`
+	TokenBoundCIDRs      []string `json:"token_bound_cidrs"`
+	TokenNoDefaultPolicy bool     `json:"token_no_default_policy"`
+	TokenNumUses         int      `json:"token_num_uses"`
+	TokenType            string   `json:"token_type,omitempty"`
+}
+
+func (r ApproleRole) toAPI() approleRoleAPI {
+	return approleRoleAPI{
+		BindSecretID:         r.BindSecretID,
+		SecretIDBoundCIDRs:   r.SecretIDBoundCIDRs,
+		SecretIDNumUses:      r.SecretIDNumUses,
+		SecretIDTTL:          int64(r.SecretIDTTL / time.Second),
+		LocalSecretIDs:       r.LocalSecretIDs,
+		TokenPolicies:        r.TokenPolicies,
+		TokenTTL:             int64(r.TokenTTL / time.Second),
+		TokenMaxTTL:          int64(r.TokenMaxTTL / time.Second),
+		TokenExplicitMaxTTL:  int64(r.TokenExplicitMaxTTL / time.Second),
+		TokenPeriod:          int64(r.TokenPeriod / time.Second),
+		TokenBoundCIDRs:      r.TokenBoundCIDRs,
+		TokenNoDefaultPolicy: r.TokenNoDefaultPolicy,
+		TokenNumUses:         r.TokenNumUses,
+		TokenType:            r.TokenType,
+	}
+}
+
+func (r approleRoleAPI) Parse() *ApproleRole {
+	return &ApproleRole{
+		BindSecretID:         r.BindSecretID,
+		SecretIDBoundCIDRs:   r.SecretIDBoundCIDRs,
+		SecretIDNumUses:      r.SecretIDNumUses,
+		SecretIDTTL:          time.Duration(r.SecretIDTTL) * time.Second,
+		LocalSecretIDs:       r.LocalSecretIDs,
+		TokenPolicies:        r.TokenPolicies,
+		TokenTTL:             time.Duration(r.TokenTTL) * time.Second,
+		TokenMaxTTL:          time.Duration(r.TokenMaxTTL) * time.Second,
+		TokenExplicitMaxTTL:  time.Duration(r.TokenExplicitMaxTTL) * time.Second,
+		TokenPeriod:          time.Duration(r.TokenPeriod) * time.Second,
+		TokenBoundCIDRs:      r.TokenBoundCIDRs,
+		TokenNoDefaultPolicy: r.TokenNoDefaultPolicy,
+		TokenNumUses:         r.TokenNumUses,
+		TokenType:            r.TokenType,
+	}
+}
+
+//approleRolePath returns the path of the given role of the approle auth method
+// at the given mount, followed by the given suffix.
+func approleRolePath(mount, role, suffix string) (string, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return "", fmt.Errorf("no mountpoint given")
+	}
+
+	role = strings.Trim(role, "/")
+	if role == "" {
+		return "", fmt.Errorf("no role given")
+	}
+
+	return fmt.Sprintf("/auth/%s/role/%s%s", mount, role, suffix), nil
+}
+
+//ApproleRoleWrite creates or updates the role with the given name in the
+// approle auth method at the given mount. Every member of the ApproleRole is
+// sent, so a role read with ApproleRoleRead can be modified and written back.
+// Given mountpoint is relative to /v1/auth.
+func (v *Client) ApproleRoleWrite(mount, name string, role ApproleRole) error {
+	return v.ApproleRoleWriteContext(context.Background(), mount, name, role)
+}
+
+//ApproleRoleWriteContext is ApproleRoleWrite with a context governing the
+// request.
+func (v *Client) ApproleRoleWriteContext(ctx context.Context, mount, name string, role ApproleRole) error {
+	path, err := approleRolePath(mount, name, "")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, role.toAPI(), nil)
+}
+
+//ApproleRoleRead returns the configuration of the role with the given name in
+// the approle auth method at the given mount. If it does not exist, an
+// *ErrNotFound is returned.
+func (v *Client) ApproleRoleRead(mount, name string) (*ApproleRole, error) {
+	return v.ApproleRoleReadContext(context.Background(), mount, name)
+}
+
+//ApproleRoleReadContext is ApproleRoleRead with a context governing the
+// request.
+func (v *Client) ApproleRoleReadContext(ctx context.Context, mount, name string) (*ApproleRole, error) {
+	path, err := approleRolePath(mount, name, "")
+	if err != nil {
+		return nil, err
+	}
+
+	raw := approleRoleAPI{}
+	err = v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Parse(), nil
+}
+
+//ApproleRoleList returns the names of all of the roles in the approle auth
+// method at the given mount.
+func (v *Client) ApproleRoleList(mount string) ([]string, error) {
+	return v.ApproleRoleListContext(context.Background(), mount)
+}
+
+//ApproleRoleListContext is ApproleRoleList with a context governing the
+// request.
+func (v *Client) ApproleRoleListContext(ctx context.Context, mount string) ([]string, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	return v.ListContext(ctx, fmt.Sprintf("/auth/%s/role", mount))
+}
+
+//ApproleRoleDelete deletes the role with the given name in the approle auth
+// method at the given mount, along with all of its secret IDs. Tokens already
+// issued to it are not revoked.
+func (v *Client) ApproleRoleDelete(mount, name string) error {
+	return v.ApproleRoleDeleteContext(context.Background(), mount, name)
+}
+
+//ApproleRoleDeleteContext is ApproleRoleDelete with a context governing the
+// request.
+func (v *Client) ApproleRoleDeleteContext(ctx context.Context, mount, name string) error {
+	path, err := approleRolePath(mount, name, "")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "DELETE", path, nil, nil)
+}
+
+//ApproleRoleID returns the role ID of the role with the given name in the
+// approle auth method at the given mount, which is given to AuthApproleMount
+// along with a secret ID.
+func (v *Client) ApproleRoleID(mount, name string) (string, error) {
+	return v.ApproleRoleIDContext(context.Background(), mount, name)
+}
+
+//ApproleRoleIDContext is ApproleRoleID with a context governing the request.
+func (v *Client) ApproleRoleIDContext(ctx context.Context, mount, name string) (string, error) {
+	path, err := approleRolePath(mount, name, "/role-id")
+	if err != nil {
+		return "", err
+	}
+
+	raw := struct {
+		RoleID string `json:"role_id"`
+	}{}
+	err = v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return "", err
+	}
+
+	return raw.RoleID, nil
+}
+
+//ApproleSecretIDOptions are the parameters of a new secret ID made with
+// ApproleSecretIDGenerate. Only non-empty values are sent, so that the
+// defaults of the role apply to the rest.
+type ApproleSecretIDOptions struct {
+	//Metadata is attached to the secret ID and to the tokens issued with it,
+	// and is shown in the audit log.
+	Metadata map[string]string
+	//CIDRList are the CIDR blocks from which the secret ID may be used to log
+	// in. They must be a subset of the SecretIDBoundCIDRs of the role.
+	CIDRList []string
+	//TokenBoundCIDRs are the CIDR blocks from which the tokens issued with the
+	// secret ID may be used. They must be a subset of the TokenBoundCIDRs of
+	// the role.
+	TokenBoundCIDRs []string
+	//NumUses is the number of times the secret ID may be used to log in. It
+	// cannot be more than the SecretIDNumUses of the role.
+	NumUses int
+	//TTL is the time after which the secret ID expires. It cannot be more than
+	// the SecretIDTTL of the role.
+	TTL time.Duration
+}
+
+//ApproleSecretID is a newly generated secret ID.
+type ApproleSecretID struct {
+	SecretID string
+	//Accessor refers to the secret ID in place of the secret ID itself, to
+	// look it up or destroy it.
+	Accessor string
+	TTL      time.Duration
+	NumUses  int
+}
+
+//ApproleSecretIDGenerate generates a new secret ID for the role with the given
+// name in the approle auth method at the given mount.
+func (v *Client) ApproleSecretIDGenerate(mount, role string, opts ApproleSecretIDOptions) (*ApproleSecretID, error) {
+	return v.ApproleSecretIDGenerateContext(context.Background(), mount, role, opts)
+}
+
+//ApproleSecretIDGenerateContext is ApproleSecretIDGenerate with a context
+// governing the request.
+func (v *Client) ApproleSecretIDGenerateContext(ctx context.Context, mount, role string, opts ApproleSecretIDOptions) (*ApproleSecretID, error) {
+	path, err := approleRolePath(mount, role, "/secret-id")
+	if err != nil {
+		return nil, err
+	}
+
+	//Vault takes the metadata as a JSON encoded string
+	var metadata string
+	if len(opts.Metadata) > 0 {
+		b, err := json.Marshal(opts.Metadata)
+		if err != nil {
+			return nil, err
+		}
+
+		metadata = string(b)
+	}
+
+	raw := struct {
+		SecretID string `json:"secret_id"`
+		Accessor string `json:"secret_id_accessor"`
+		TTL      int64  `json:"secret_id_ttl"`
+		NumUses  int    `json:"secret_id_num_uses"`
+	}{}
+	err = v.doRequest(ctx, "POST", path, struct {
+		Metadata        string   `json:"metadata,omitempty"`
+		CIDRList        []string `json:"cidr_list,omitempty"`
+		TokenBoundCIDRs []string `json:"token_bound_cidrs,omitempty"`
+		NumUses         int      `json:"num_uses,omitempty"`
+		TTL             string   `json:"ttl,omitempty"`
+	}{
+		Metadata:        metadata,
+		CIDRList:        opts.CIDRList,
+		TokenBoundCIDRs: opts.TokenBoundCIDRs,
+		NumUses:         opts.NumUses,
+		TTL:             durationSeconds(opts.TTL),
+	}, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApproleSecretID{
+		SecretID: raw.SecretID,
+		Accessor: raw.Accessor,
+		TTL:      time.Duration(raw.TTL) * time.Second,
+		NumUses:  raw.NumUses,
+	}, nil
+}
+
+//ApproleSecretIDGenerateWrapped is ApproleSecretIDGenerate, with the response
+// wrapped for the given TTL, such that the secret ID can be handed to the
+// service that logs in with it without being seen by anything else. The
+// service gets the secret ID by unwrapping the token of the returned WrapInfo.
+// See RequestWrapped.
+func (v *Client) ApproleSecretIDGenerateWrapped(mount, role string, opts ApproleSecretIDOptions, ttl time.Duration) (*WrapInfo, error) {
+	return v.ApproleSecretIDGenerateWrappedContext(context.Background(), mount, role, opts, ttl)
+}
+
+//ApproleSecretIDGenerateWrappedContext is ApproleSecretIDGenerateWrapped with a
+// context governing the request.
+func (v *Client) ApproleSecretIDGenerateWrappedContext(ctx context.Context, mount, role string, opts ApproleSecretIDOptions, ttl time.Duration) (*WrapInfo, error) {
+	return v.RequestWrapped(ctx, ttl, func(ctx context.Context) error {
+		_, err := v.ApproleSecretIDGenerateContext(ctx, mount, role, opts)
+		return err
+	})
+}
+
+//ApproleSecretIDInfo describes an existing secret ID.
+type ApproleSecretIDInfo struct {
+	Accessor        string
+	Metadata        map[string]string
+	CIDRList        []string
+	TokenBoundCIDRs []string
+	//NumUses is the number of uses the secret ID has left. Zero means
+	// unlimited.
+	NumUses      int
+	TTL          time.Duration
+	CreationTime time.Time
+	//ExpirationTime is the zero time if the secret ID does not expire.
+	ExpirationTime  time.Time
+	LastUpdatedTime time.Time
+}
+
+type approleSecretIDInfoAPI struct {
+	Accessor        string            `json:"secret_id_accessor"`
+	Metadata        map[string]string `json:"metadata"`
+	CIDRList        []string          `json:"cidr_list"`
+	TokenBoundCIDRs []string          `json:"token_bound_cidrs"`
+	NumUses         int               `json:"secret_id_num_uses"`
+	TTL             int64             `json:"secret_id_ttl"`
+	CreationTime    string            `json:"creation_time"`
+	ExpirationTime  string            `json:"expiration_time"`
+	LastUpdatedTime string            `json:"last_updated_time"`
+}
+
+func (i approleSecretIDInfoAPI) Parse() *ApproleSecretIDInfo {
+	ret := &ApproleSecretIDInfo{
+		Accessor:        i.Accessor,
+		Metadata:        i.Metadata,
+		CIDRList:        i.CIDRList,
+		TokenBoundCIDRs: i.TokenBoundCIDRs,
+		NumUses:         i.NumUses,
+		TTL:             time.Duration(i.TTL) * time.Second,
+	}
+
+	ret.CreationTime, _ = time.Parse(time.RFC3339Nano, i.CreationTime)
+	ret.ExpirationTime, _ = time.Parse(time.RFC3339Nano, i.ExpirationTime)
+	ret.LastUpdatedTime, _ = time.Parse(time.RFC3339Nano, i.LastUpdatedTime)
+	return ret
+}
+
+//ApproleSecretIDLookup returns information about the given secret ID of the
+// role with the given name in the approle auth method at the given mount. If
+// the secret ID does not exist, an *ErrNotFound is returned.
+func (v *Client) ApproleSecretIDLookup(mount, role, secretID string) (*ApproleSecretIDInfo, error) {
+	return v.ApproleSecretIDLookupContext(context.Background(), mount, role, secretID)
+}
+
+//ApproleSecretIDLookupContext is ApproleSecretIDLookup with a context governing
+// the request.
+func (v *Client) ApproleSecretIDLookupContext(ctx context.Context, mount, role, secretID string) (*ApproleSecretIDInfo, error) {
+	return v.approleSecretIDLookup(ctx, mount, role, "/secret-id/lookup", struct {
+		SecretID string `json:"secret_id"`
+	}{
+		SecretID: secretID,
+	})
+}
+
+//ApproleSecretIDAccessorLookup returns information about the secret ID with
+// the given accessor of the role with the given name in the approle auth
+// method at the given mount. If the secret ID does not exist, an *ErrNotFound
+// is returned.
+func (v *Client) ApproleSecretIDAccessorLookup(mount, role, accessor string) (*ApproleSecretIDInfo, error) {
+	return v.ApproleSecretIDAccessorLookupContext(context.Background(), mount, role, accessor)
+}
+
+//ApproleSecretIDAccessorLookupContext is ApproleSecretIDAccessorLookup with a
+// context governing the request.
+func (v *Client) ApproleSecretIDAccessorLookupContext(ctx context.Context, mount, role, accessor string) (*ApproleSecretIDInfo, error) {
+	return v.approleSecretIDLookup(ctx, mount, role, "/secret-id-accessor/lookup", struct {
+		Accessor string `json:"secret_id_accessor"`
+	}{
+		Accessor: accessor,
+	})
+}
+
+func (v *Client) approleSecretIDLookup(ctx context.Context, mount, role, suffix string, input interface{}) (*ApproleSecretIDInfo, error) {
+	path, err := approleRolePath(mount, role, suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	//Some versions of Vault respond to a secret ID which does not exist with no
+	// content, instead of a 404
+	var raw *approleSecretIDInfoAPI
+	err = v.doRequest(ctx, "POST", path, input, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	if raw == nil {
+		return nil, &ErrNotFound{message: "No such secret ID"}
+	}
+
+	return raw.Parse(), nil
+}
+
+//ApproleSecretIDListAccessors returns the accessors of all of the secret IDs of
+// the role with the given name in the approle auth method at the given mount.
+func (v *Client) ApproleSecretIDListAccessors(mount, role string) ([]string, error) {
+	return v.ApproleSecretIDListAccessorsContext(context.Background(), mount, role)
+}
+
+//ApproleSecretIDListAccessorsContext is ApproleSecretIDListAccessors with a
+// context governing the request.
+func (v *Client) ApproleSecretIDListAccessorsContext(ctx context.Context, mount, role string) ([]string, error) {
+	path, err := approleRolePath(mount, role, "/secret-id")
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ListContext(ctx, path)
+}
+
+//ApproleSecretIDDestroy destroys the given secret ID of the role with the
+// given name in the approle auth method at the given mount, such that it can
+// no longer be used to log in. Tokens already issued with it are not revoked.
+func (v *Client) ApproleSecretIDDestroy(mount, role, secretID string) error {
+	return v.ApproleSecretIDDestroyContext(context.Background(), mount, role, secretID)
+}
+
+//ApproleSecretIDDestroyContext is ApproleSecretIDDestroy with a context
+// governing the request.
+func (v *Client) ApproleSecretIDDestroyContext(ctx context.Context, mount, role, secretID string) error {
+	path, err := approleRolePath(mount, role, "/secret-id/destroy")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		SecretID string `json:"secret_id"`
+	}{
+		SecretID: secretID,
+	}, nil)
+}
+
+//ApproleSecretIDAccessorDestroy destroys the secret ID with the given accessor
+// of the role with the given name in the approle auth method at the given
+// mount, such that it can no longer be used to log in.
+func (v *Client) ApproleSecretIDAccessorDestroy(mount, role, accessor string) error {
+	return v.ApproleSecretIDAccessorDestroyContext(context.Background(), mount, role, accessor)
+}
+
+//ApproleSecretIDAccessorDestroyContext is ApproleSecretIDAccessorDestroy with
+// a context governing the request.
+func (v *Client) ApproleSecretIDAccessorDestroyContext(ctx context.Context, mount, role, accessor string) error {
+	path, err := approleRolePath(mount, role, "/secret-id-accessor/destroy")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		Accessor string `json:"secret_id_accessor"`
+	}{
+		Accessor: accessor,
+	}, nil)
+}
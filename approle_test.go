@@ -0,0 +1,173 @@
+package vaultkv_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Approle", func() {
+	var role vaultkv.ApproleRole
+
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		err = vault.EnableAuthMount("approle", vaultkv.Mount{Type: "approle"})
+		Expect(err).NotTo(HaveOccurred())
+
+		role = vaultkv.ApproleRole{
+			BindSecretID:       true,
+			SecretIDBoundCIDRs: []string{"127.0.0.0/8"},
+			SecretIDNumUses:    5,
+			SecretIDTTL:        time.Hour,
+			TokenPolicies:      []string{"default"},
+			TokenTTL:           20 * time.Minute,
+			TokenMaxTTL:        time.Hour,
+		}
+	})
+
+	JustBeforeEach(func() {
+		err = vault.ApproleRoleWrite("approle", "deploy", role)
+	})
+
+	It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+	Describe("ApproleRoleRead", func() {
+		var read *vaultkv.ApproleRole
+		JustBeforeEach(func() {
+			read, err = vault.ApproleRoleRead("approle", "deploy")
+		})
+
+		It("should return the role as it was written", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.BindSecretID).To(BeTrue())
+			Expect(read.SecretIDBoundCIDRs).To(Equal([]string{"127.0.0.0/8"}))
+			Expect(read.SecretIDNumUses).To(Equal(5))
+			Expect(read.SecretIDTTL).To(Equal(time.Hour))
+			Expect(read.TokenPolicies).To(Equal([]string{"default"}))
+			Expect(read.TokenTTL).To(Equal(20 * time.Minute))
+			Expect(read.TokenMaxTTL).To(Equal(time.Hour))
+		})
+	})
+
+	Describe("ApproleRoleList", func() {
+		var roles []string
+		JustBeforeEach(func() {
+			roles, err = vault.ApproleRoleList("approle")
+		})
+
+		It("should list the role", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(roles).To(Equal([]string{"deploy"}))
+		})
+	})
+
+	Describe("ApproleRoleDelete", func() {
+		JustBeforeEach(func() {
+			err = vault.ApproleRoleDelete("approle", "deploy")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = vault.ApproleRoleRead("approle", "deploy")
+		})
+
+		It("should delete the role", AssertErrorOfType(&vaultkv.ErrNotFound{}))
+	})
+
+	Describe("Logging in with a generated secret ID", func() {
+		var roleID string
+		var secretID *vaultkv.ApproleSecretID
+		var login *vaultkv.AuthOutput
+
+		JustBeforeEach(func() {
+			roleID, err = vault.ApproleRoleID("approle", "deploy")
+			Expect(err).NotTo(HaveOccurred())
+
+			secretID, err = vault.ApproleSecretIDGenerate("approle", "deploy", vaultkv.ApproleSecretIDOptions{
+				Metadata: map[string]string{"service": "web"},
+				NumUses:  2,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			login, err = vault.WithToken("").AuthApprole(roleID, secretID.SecretID)
+		})
+
+		It("should log in with the policies of the role", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secretID.Accessor).NotTo(BeEmpty())
+			Expect(secretID.NumUses).To(Equal(2))
+			Expect(login.Policies).To(ContainElement("default"))
+			Expect(login.LeaseDuration).To(Equal(20 * time.Minute))
+		})
+
+		Describe("ApproleSecretIDLookup", func() {
+			var info *vaultkv.ApproleSecretIDInfo
+			JustBeforeEach(func() {
+				info, err = vault.ApproleSecretIDLookup("approle", "deploy", secretID.SecretID)
+			})
+
+			It("should describe the secret ID with one use spent", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Accessor).To(Equal(secretID.Accessor))
+				Expect(info.Metadata).To(Equal(map[string]string{"service": "web"}))
+				Expect(info.NumUses).To(Equal(1))
+				Expect(info.CreationTime).NotTo(BeZero())
+			})
+		})
+
+		Describe("ApproleSecretIDListAccessors", func() {
+			var accessors []string
+			JustBeforeEach(func() {
+				accessors, err = vault.ApproleSecretIDListAccessors("approle", "deploy")
+			})
+
+			It("should list the accessor of the secret ID", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(accessors).To(Equal([]string{secretID.Accessor}))
+			})
+		})
+
+		Describe("ApproleSecretIDAccessorDestroy", func() {
+			JustBeforeEach(func() {
+				err = vault.ApproleSecretIDAccessorDestroy("approle", "deploy", secretID.Accessor)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.ApproleSecretIDListAccessors("approle", "deploy")
+			})
+
+			It("should leave no secret IDs to list", AssertErrorOfType(&vaultkv.ErrNotFound{}))
+		})
+
+		Describe("ApproleSecretIDDestroy", func() {
+			JustBeforeEach(func() {
+				err = vault.ApproleSecretIDDestroy("approle", "deploy", secretID.SecretID)
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.WithToken("").AuthApprole(roleID, secretID.SecretID)
+			})
+
+			It("should stop the secret ID from being used", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("ApproleSecretIDGenerateWrapped", func() {
+		var info *vaultkv.WrapInfo
+		JustBeforeEach(func() {
+			info, err = vault.ApproleSecretIDGenerateWrapped("approle", "deploy", vaultkv.ApproleSecretIDOptions{}, time.Minute)
+		})
+
+		It("should return a wrapping token for the secret ID", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Token).NotTo(BeEmpty())
+			Expect(info.CreationPath).To(Equal("auth/approle/role/deploy/secret-id"))
+
+			unwrapped := struct {
+				Data struct {
+					SecretID string `json:"secret_id"`
+				} `json:"data"`
+			}{}
+			err = vault.Unwrap(info.Token, &unwrapped)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwrapped.Data.SecretID).NotTo(BeEmpty())
+		})
+	})
+})
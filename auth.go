@@ -1,8 +1,10 @@
 package vaultkv
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -17,10 +19,17 @@ func (v *Client) SetAuthToken(token string) {
 	v.tokenLock.Unlock()
 }
 
+func (v *Client) authToken() string {
+	v.tokenLock.RLock()
+	defer v.tokenLock.RUnlock()
+	return v.AuthToken
+}
+
 //AuthOutput is the general structure as returned by AuthX functions. The
-//Metadata member type is determined by the specific Auth function. Note that
-//the Vault must be initialized and unsealed in order to use authentication
-//endpoints.
+//Metadata member type is determined by the specific Auth function, such as
+//AuthGithubMetadata for AuthGithub, and it is nil if the Vault returned no
+//metadata. Note that the Vault must be initialized and unsealed in order to use
+//authentication endpoints.
 type AuthOutput struct {
 	Renewable     bool
 	LeaseDuration time.Duration
@@ -35,19 +44,21 @@ type authOutputRaw struct {
 	Renewable     bool `json:"renewable"`
 	Data map[string]interface{} `json:"data"`
 	LeaseDuration int  `json:"lease_duration"`
-	Auth          struct {
-		ClientToken   string                 `json:"client_token"`
-		Accessor      string                 `json:"accessor"`
-		Policies      []string               `json:"policies"`
-		Renewable     bool                   `json:"renewable"`
-		LeaseDuration int                    `json:"lease_duration"`
-		Metadata      map[string]interface{} `json:"metadata"`
-	} `json:"auth"`
+	Auth          authAPI `json:"auth"`
 	//Metadata's internal structure is dependent on the auth type
 	Metadata map[string]interface{} `json:"metadata"`
 }
 
-func (a authOutputRaw) toFinal(m interface{}) *AuthOutput {
+type authAPI struct {
+	ClientToken   string                 `json:"client_token"`
+	Accessor      string                 `json:"accessor"`
+	Policies      []string               `json:"policies"`
+	Renewable     bool                   `json:"renewable"`
+	LeaseDuration int                    `json:"lease_duration"`
+	Metadata      map[string]interface{} `json:"metadata"`
+}
+
+func (a authOutputRaw) toFinal(m interface{}) (*AuthOutput, error) {
 	ret := &AuthOutput{
 		ClientToken:   a.Auth.ClientToken,
 		Accessor:      a.Auth.Accessor,
@@ -63,24 +74,28 @@ func (a authOutputRaw) toFinal(m interface{}) *AuthOutput {
 	}
 
 	if len(metadata) != 0 && m != nil {
-		b, err := json.Marshal(&ret.Metadata)
+		b, err := json.Marshal(metadata)
 		if err != nil {
-			panic("could not marshal map that we created")
+			return nil, err
 		}
 
-		err = json.Unmarshal(b, &m)
+		//Decode into a new value of the type of m, so that the Metadata is of
+		// that type rather than a map. The metadata comes from the server, so it
+		// may not fit the type.
+		target := reflect.New(reflect.TypeOf(m))
+		err = json.Unmarshal(b, target.Interface())
 		if err != nil {
-			panic("could not unmarshal json that we created")
+			return nil, fmt.Errorf("Could not parse auth metadata: %s", err)
 		}
 
-		ret.Metadata = m
+		ret.Metadata = target.Elem().Interface()
 	}
 
 	if ret.LeaseDuration == 0 {
 		ret.LeaseDuration = time.Duration(a.Auth.LeaseDuration) * time.Second
 	}
 
-	return ret
+	return ret, nil
 }
 
 //AuthGithubMetadata is the metadata member set by AuthGithub.
@@ -95,12 +110,23 @@ func (v *Client) AuthGithub(accessToken string) (ret *AuthOutput, err error) {
 	return v.AuthGithubMount("github", accessToken)
 }
 
+//AuthGithubContext is AuthGithub with a context governing the request.
+func (v *Client) AuthGithubContext(ctx context.Context, accessToken string) (ret *AuthOutput, err error) {
+	return v.AuthGithubMountContext(ctx, "github", accessToken)
+}
+
 //AuthGithubMount submits the given accessToken to the github auth endpoint at
 //the given mount, checking it against configurations for Github organizations.
 //If the accessToken belongs to an authorized account, then the AuthOutput
 //object is returned, and this client's AuthToken is set to the returned token.
 //Given mountpoint is relative to /v1/auth.
 func (v *Client) AuthGithubMount(mount, accessToken string) (ret *AuthOutput, err error) {
+	return v.AuthGithubMountContext(context.Background(), mount, accessToken)
+}
+
+//AuthGithubMountContext is AuthGithubMount with a context governing the
+//request.
+func (v *Client) AuthGithubMountContext(ctx context.Context, mount, accessToken string) (ret *AuthOutput, err error) {
 	raw := &authOutputRaw{}
 
 	mount = strings.Trim(mount, "/")
@@ -109,6 +135,7 @@ func (v *Client) AuthGithubMount(mount, accessToken string) (ret *AuthOutput, er
 	}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("/auth/%s/login", mount),
 		struct {
@@ -119,8 +146,11 @@ func (v *Client) AuthGithubMount(mount, accessToken string) (ret *AuthOutput, er
 	if err != nil {
 		return
 	}
-	ret = raw.toFinal(AuthGithubMetadata{})
-	v.AuthToken = ret.ClientToken
+	ret, err = raw.toFinal(AuthGithubMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
 
 	return
 }
@@ -136,12 +166,22 @@ func (v *Client) AuthOkta(username, password string) (ret *AuthOutput, err error
 	return v.AuthOktaMount("okta", username, password)
 }
 
+//AuthOktaContext is AuthOkta with a context governing the request.
+func (v *Client) AuthOktaContext(ctx context.Context, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthOktaMountContext(ctx, "okta", username, password)
+}
+
 //AuthOktaMount submits the given username and password to the Okta auth endpoint
 //mounted at the given mountpoint, checking it against existing Okta auth
 //configurations. If auth is successful, then the AuthOutput object is returned,
 //and this client's AuthToken is set to the returned token. Given mountpoint is
 //relative to /v1/auth.
 func (v *Client) AuthOktaMount(mount, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthOktaMountContext(context.Background(), mount, username, password)
+}
+
+//AuthOktaMountContext is AuthOktaMount with a context governing the request.
+func (v *Client) AuthOktaMountContext(ctx context.Context, mount, username, password string) (ret *AuthOutput, err error) {
 	raw := &authOutputRaw{}
 
 	mount = strings.Trim(mount, "/")
@@ -150,6 +190,7 @@ func (v *Client) AuthOktaMount(mount, username, password string) (ret *AuthOutpu
 	}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("/auth/%s/login/%s", mount, username),
 		struct {
@@ -157,13 +198,15 @@ func (v *Client) AuthOktaMount(mount, username, password string) (ret *AuthOutpu
 		}{Password: password},
 		&raw,
 	)
-	fmt.Sprintf("%s", err)
 	if err != nil {
 		return
 	}
 
-	ret = raw.toFinal(AuthOktaMetadata{})
-	v.AuthToken = ret.ClientToken
+	ret, err = raw.toFinal(AuthOktaMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
 
 	return
 }
@@ -179,12 +222,22 @@ func (v *Client) AuthLDAP(username, password string) (ret *AuthOutput, err error
 	return v.AuthLDAPMount("ldap", username, password)
 }
 
+//AuthLDAPContext is AuthLDAP with a context governing the request.
+func (v *Client) AuthLDAPContext(ctx context.Context, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthLDAPMountContext(ctx, "ldap", username, password)
+}
+
 //AuthLDAPMount submits the given username and password to the LDAP auth endpoint
 //mounted at the given mountpoint, checking it against existing LDAP auth
 //configurations. If auth is successful, then the AuthOutput object is returned,
 //and this client's AuthToken is set to the returned token. Given mountpoint is
 //relative to /v1/auth.
 func (v *Client) AuthLDAPMount(mount, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthLDAPMountContext(context.Background(), mount, username, password)
+}
+
+//AuthLDAPMountContext is AuthLDAPMount with a context governing the request.
+func (v *Client) AuthLDAPMountContext(ctx context.Context, mount, username, password string) (ret *AuthOutput, err error) {
 	raw := &authOutputRaw{}
 
 	mount = strings.Trim(mount, "/")
@@ -193,6 +246,7 @@ func (v *Client) AuthLDAPMount(mount, username, password string) (ret *AuthOutpu
 	}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("/auth/%s/login/%s", mount, username),
 		struct {
@@ -204,8 +258,11 @@ func (v *Client) AuthLDAPMount(mount, username, password string) (ret *AuthOutpu
 		return
 	}
 
-	ret = raw.toFinal(AuthLDAPMetadata{})
-	v.AuthToken = ret.ClientToken
+	ret, err = raw.toFinal(AuthLDAPMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
 
 	return
 }
@@ -221,11 +278,22 @@ func (v *Client) AuthUserpass(username, password string) (ret *AuthOutput, err e
 	return v.AuthUserpassMount("userpass", username, password)
 }
 
+//AuthUserpassContext is AuthUserpass with a context governing the request.
+func (v *Client) AuthUserpassContext(ctx context.Context, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthUserpassMountContext(ctx, "userpass", username, password)
+}
+
 //AuthUserpass submits the given username and password to the userpass auth
 //endpoint located at the given mount. If a username with that password exists,
 //then the AuthOutput object is returned, and this client's AuthToken is set to
 //the returned token. Given mountpoint is relative to /v1/auth.
 func (v *Client) AuthUserpassMount(mount, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthUserpassMountContext(context.Background(), mount, username, password)
+}
+
+//AuthUserpassMountContext is AuthUserpassMount with a context governing the
+//request.
+func (v *Client) AuthUserpassMountContext(ctx context.Context, mount, username, password string) (ret *AuthOutput, err error) {
 	raw := &authOutputRaw{}
 
 	mount = strings.Trim(mount, "/")
@@ -234,6 +302,7 @@ func (v *Client) AuthUserpassMount(mount, username, password string) (ret *AuthO
 	}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("/auth/%s/login/%s", mount, username),
 		struct {
@@ -245,8 +314,11 @@ func (v *Client) AuthUserpassMount(mount, username, password string) (ret *AuthO
 		return
 	}
 
-	ret = raw.toFinal(AuthUserpassMetadata{})
-	v.AuthToken = ret.ClientToken
+	ret, err = raw.toFinal(AuthUserpassMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
 
 	return
 }
@@ -258,10 +330,21 @@ func (v *Client) AuthApprole(roleID, secretID string) (ret *AuthOutput, err erro
 	return v.AuthApproleMount("approle", roleID, secretID)
 }
 
+//AuthApproleContext is AuthApprole with a context governing the request.
+func (v *Client) AuthApproleContext(ctx context.Context, roleID, secretID string) (ret *AuthOutput, err error) {
+	return v.AuthApproleMountContext(ctx, "approle", roleID, secretID)
+}
+
 //AuthApproleMount performs auth against the given approle mount with the given
 // approle ID and secret. If the login is successful, this client's AuthToken is
 // set to the returned token.
 func (v *Client) AuthApproleMount(mount, roleID, secretID string) (ret *AuthOutput, err error) {
+	return v.AuthApproleMountContext(context.Background(), mount, roleID, secretID)
+}
+
+//AuthApproleMountContext is AuthApproleMount with a context governing the
+//request.
+func (v *Client) AuthApproleMountContext(ctx context.Context, mount, roleID, secretID string) (ret *AuthOutput, err error) {
 	raw := &authOutputRaw{}
 
 	mount = strings.Trim(mount, "/")
@@ -270,6 +353,7 @@ func (v *Client) AuthApproleMount(mount, roleID, secretID string) (ret *AuthOutp
 	}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("/auth/%s/login", mount),
 		struct {
@@ -285,8 +369,173 @@ func (v *Client) AuthApproleMount(mount, roleID, secretID string) (ret *AuthOutp
 		return
 	}
 
-	ret = raw.toFinal(nil)
-	v.AuthToken = ret.ClientToken
+	ret, err = raw.toFinal(nil)
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
+
+	return
+}
+
+//AuthKubernetesMetadata is the metadata member set by AuthKubernetes
+type AuthKubernetesMetadata struct {
+	Role                     string `json:"role"`
+	ServiceAccountName       string `json:"service_account_name"`
+	ServiceAccountNamespace  string `json:"service_account_namespace"`
+	ServiceAccountUID        string `json:"service_account_uid"`
+	ServiceAccountSecretName string `json:"service_account_secret_name"`
+}
+
+//DefaultKubernetesTokenPath is where Kubernetes mounts the service account
+// token of a pod, and where AuthKubernetesServiceAccount reads it from by
+// default.
+const DefaultKubernetesTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+//AuthKubernetes is a shorthand for AuthKubernetesMount against the default
+// kubernetes mountpoint, 'kubernetes'.
+func (v *Client) AuthKubernetes(role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthKubernetesMount("kubernetes", role, jwt)
+}
+
+//AuthKubernetesContext is AuthKubernetes with a context governing the request.
+func (v *Client) AuthKubernetesContext(ctx context.Context, role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthKubernetesMountContext(ctx, "kubernetes", role, jwt)
+}
+
+//AuthKubernetesMount submits the given service account JWT to the kubernetes
+// auth endpoint at the given mount, to log in as the given role. If the service
+// account is bound to the role, then the AuthOutput object is returned, with
+// Metadata of type AuthKubernetesMetadata, and this client's AuthToken is set
+// to the returned token. Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthKubernetesMount(mount, role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthKubernetesMountContext(context.Background(), mount, role, jwt)
+}
+
+//AuthKubernetesMountContext is AuthKubernetesMount with a context governing
+//the request.
+func (v *Client) AuthKubernetesMountContext(ctx context.Context, mount, role, jwt string) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	err = v.doRequest(
+		ctx,
+		"POST",
+		fmt.Sprintf("/auth/%s/login", mount),
+		struct {
+			Role string `json:"role"`
+			JWT  string `json:"jwt"`
+		}{
+			Role: role,
+			JWT:  jwt,
+		},
+		&raw,
+	)
+	if err != nil {
+		return
+	}
+
+	ret, err = raw.toFinal(AuthKubernetesMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
+
+	return
+}
+
+//AuthKubernetesServiceAccount performs AuthKubernetesMount with the service
+// account token of the pod that this is running in, read from the file at
+// tokenPath. If tokenPath is empty, DefaultKubernetesTokenPath is used. The
+// file is read on each call, as projected service account tokens are rotated
+// by the kubelet.
+func (v *Client) AuthKubernetesServiceAccount(mount, role, tokenPath string) (ret *AuthOutput, err error) {
+	return v.AuthKubernetesServiceAccountContext(context.Background(), mount, role, tokenPath)
+}
+
+//AuthKubernetesServiceAccountContext is AuthKubernetesServiceAccount with a
+// context governing the request.
+func (v *Client) AuthKubernetesServiceAccountContext(ctx context.Context, mount, role, tokenPath string) (ret *AuthOutput, err error) {
+	if tokenPath == "" {
+		tokenPath = DefaultKubernetesTokenPath
+	}
+
+	jwt, err := readCredentialFile(tokenPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.AuthKubernetesMountContext(ctx, mount, role, jwt)
+}
+
+//AuthCertMetadata is the metadata member set by AuthCert
+type AuthCertMetadata struct {
+	//CertName is the name of the certificate role that was logged in as
+	CertName       string `json:"cert_name"`
+	CommonName     string `json:"common_name"`
+	SerialNumber   string `json:"serial_number"`
+	SubjectKeyID   string `json:"subject_key_id"`
+	AuthorityKeyID string `json:"authority_key_id"`
+}
+
+//AuthCert is a shorthand for AuthCertMount against the default cert
+// mountpoint, 'cert'.
+func (v *Client) AuthCert(name string) (ret *AuthOutput, err error) {
+	return v.AuthCertMount("cert", name)
+}
+
+//AuthCertContext is AuthCert with a context governing the request.
+func (v *Client) AuthCertContext(ctx context.Context, name string) (ret *AuthOutput, err error) {
+	return v.AuthCertMountContext(ctx, "cert", name)
+}
+
+//AuthCertMount logs in to the cert auth endpoint at the given mount with the
+// client certificate that this client presents when connecting to the Vault,
+// which is the ClientCert of its TLS settings, unless it has been given an
+// HTTP Client of its own. If name is non-empty, only the certificate role with
+// that name is checked, and otherwise, every role is. If the certificate is
+// trusted, then the AuthOutput object is returned, with Metadata of type
+// AuthCertMetadata, and this client's AuthToken is set to the returned token.
+// Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthCertMount(mount, name string) (ret *AuthOutput, err error) {
+	return v.AuthCertMountContext(context.Background(), mount, name)
+}
+
+//AuthCertMountContext is AuthCertMount with a context governing the request.
+func (v *Client) AuthCertMountContext(ctx context.Context, mount, name string) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	if v.Client == nil && (v.TLS == nil || v.TLS.ClientCert == "") {
+		return nil, fmt.Errorf("no client certificate configured")
+	}
+
+	err = v.doRequest(
+		ctx,
+		"POST",
+		fmt.Sprintf("/auth/%s/login", mount),
+		struct {
+			Name string `json:"name,omitempty"`
+		}{Name: name},
+		&raw,
+	)
+	if err != nil {
+		return
+	}
+
+	ret, err = raw.toFinal(AuthCertMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
 
 	return
 }
@@ -294,7 +543,38 @@ func (v *Client) AuthApproleMount(mount, roleID, secretID string) (ret *AuthOutp
 //TokenRenewSelf takes the token in the Client object and attempts to renew its
 // lease.
 func (v *Client) TokenRenewSelf() (err error) {
-	return v.doRequest("POST", "/auth/token/renew-self", nil, nil)
+	return v.TokenRenewSelfContext(context.Background())
+}
+
+//TokenRenewSelfContext is TokenRenewSelf with a context governing the request.
+func (v *Client) TokenRenewSelfContext(ctx context.Context) (err error) {
+	return v.doRequest(ctx, "POST", "/auth/token/renew-self", nil, nil)
+}
+
+//TokenRenewSelfIncrement renews the lease of the token in the Client object,
+// asking for the given increment from now to be added to it, and returns the
+// resulting lease. Vault may grant a shorter lease than the increment asked
+// for, such as when the token nears its max TTL, so the LeaseDuration of the
+// output should be checked. An increment of zero asks for the token's default
+// TTL.
+func (v *Client) TokenRenewSelfIncrement(increment time.Duration) (ret *AuthOutput, err error) {
+	return v.TokenRenewSelfIncrementContext(context.Background(), increment)
+}
+
+//TokenRenewSelfIncrementContext is TokenRenewSelfIncrement with a context
+// governing the request.
+func (v *Client) TokenRenewSelfIncrementContext(ctx context.Context, increment time.Duration) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+	err = v.doRequest(ctx, "POST", "/auth/token/renew-self", struct {
+		Increment int64 `json:"increment,omitempty"`
+	}{
+		Increment: int64(increment / time.Second),
+	}, raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal(nil)
 }
 
 //TokenInfo contains metadata about a token. Return values from the Vault API
@@ -309,6 +589,7 @@ type TokenInfo struct {
 	ExplicitMaxTTL time.Duration
 	ID             string
 	IssueTime      time.Time
+	Meta           map[string]string
 	NumUses        int64
 	Orphan         bool
 	Path           string
@@ -319,32 +600,42 @@ type TokenInfo struct {
 
 type tokenInfoRaw struct {
 	Data struct {
-		Accessor       string   `json:"accessor"`
-		CreationTime   int64    `json:"creation_time"`
-		CreationTTL    int64    `json:"creation_ttl"`
-		DisplayName    string   `json:"display_name"`
-		EntityID       string   `json:"entity_id"`
-		ExpireTime     string   `json:"expire_time"`
-		ExplicitMaxTTL int64    `json:"explicit_max_ttl"`
-		ID             string   `json:"id"`
-		IssueTime      string   `json:"issue_time"`
-		NumUses        int64    `json:"num_uses"`
-		Orphan         bool     `json:"orphan"`
-		Path           string   `json:"path"`
-		Policies       []string `json:"policies"`
-		Renewable      bool     `json:"renewable"`
-		TTL            int64    `json:"ttl"`
+		Accessor       string            `json:"accessor"`
+		CreationTime   int64             `json:"creation_time"`
+		CreationTTL    int64             `json:"creation_ttl"`
+		DisplayName    string            `json:"display_name"`
+		EntityID       string            `json:"entity_id"`
+		ExpireTime     string            `json:"expire_time"`
+		ExplicitMaxTTL int64             `json:"explicit_max_ttl"`
+		ID             string            `json:"id"`
+		IssueTime      string            `json:"issue_time"`
+		Meta           map[string]string `json:"meta"`
+		NumUses        int64             `json:"num_uses"`
+		Orphan         bool              `json:"orphan"`
+		Path           string            `json:"path"`
+		Policies       []string          `json:"policies"`
+		Renewable      bool              `json:"renewable"`
+		TTL            int64             `json:"ttl"`
 	} `json:"data"`
 }
 
 //TokenInfoSelf returns the contents of the token self info endpoint of the vault
 func (v *Client) TokenInfoSelf() (ret *TokenInfo, err error) {
+	return v.TokenInfoSelfContext(context.Background())
+}
+
+//TokenInfoSelfContext is TokenInfoSelf with a context governing the request.
+func (v *Client) TokenInfoSelfContext(ctx context.Context) (ret *TokenInfo, err error) {
 	raw := tokenInfoRaw{}
-	err = v.doRequest("GET", "/auth/token/lookup-self", nil, &raw)
+	err = v.doRequest(ctx, "GET", "/auth/token/lookup-self", nil, &raw)
 	if err != nil {
 		return
 	}
 
+	return raw.toFinal()
+}
+
+func (raw tokenInfoRaw) toFinal() (ret *TokenInfo, err error) {
 	var expTime, issTime time.Time
 	if raw.Data.ExpireTime != "" {
 		expTime, err = time.Parse(time.RFC3339Nano, raw.Data.ExpireTime)
@@ -370,6 +661,7 @@ func (v *Client) TokenInfoSelf() (ret *TokenInfo, err error) {
 		ExplicitMaxTTL: time.Duration(raw.Data.ExplicitMaxTTL) * time.Second,
 		ID:             raw.Data.ID,
 		IssueTime:      issTime,
+		Meta:           raw.Data.Meta,
 		NumUses:        raw.Data.NumUses,
 		Orphan:         raw.Data.Orphan,
 		Path:           raw.Data.Path,
@@ -385,5 +677,10 @@ func (v *Client) TokenInfoSelf() (ret *TokenInfo, err error) {
 // if the token is valid but somebody has configured policies such that it can not
 // look itself up. It can also error, of course, if the token is invalid.
 func (v *Client) TokenIsValid() (err error) {
-	return v.doRequest("GET", "/auth/token/lookup-self", nil, nil)
+	return v.TokenIsValidContext(context.Background())
+}
+
+//TokenIsValidContext is TokenIsValid with a context governing the request.
+func (v *Client) TokenIsValidContext(ctx context.Context) (err error) {
+	return v.doRequest(ctx, "GET", "/auth/token/lookup-self", nil, nil)
 }
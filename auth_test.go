@@ -1,6 +1,23 @@
 package vaultkv_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -53,3 +70,111 @@ var _ = Describe("Auth", func() {
 		})
 	})
 })
+
+//writeClientKeyPair writes a self-signed client certificate with the given
+// common name, and its key, to the given paths.
+func writeClientKeyPair(certPath, keyPath, commonName string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Second),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644)
+	Expect(err).NotTo(HaveOccurred())
+	err = ioutil.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+var _ = Describe("AuthCert", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var tmpDir string
+	var login map[string]string
+	var presented string
+	var loginBody string
+	var output *vaultkv.AuthOutput
+
+	BeforeEach(func() {
+		login, presented = nil, ""
+		loginBody = `{"auth":{"client_token":"s.cert","metadata":{"cert_name":"web","common_name":"web.example.com","serial_number":"3"}}}`
+		tmpDir, err = ioutil.TempDir("", "vaultkv-test-cert")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			Expect(r.URL.Path).To(Equal("/v1/auth/cert/login"))
+			Expect(json.NewDecoder(r.Body).Decode(&login)).To(Succeed())
+			presented = r.TLS.PeerCertificates[0].Subject.CommonName
+			_, _ = w.Write([]byte(loginBody))
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		tlsConfig := &vaultkv.TLSConfig{
+			ClientCert:         filepath.Join(tmpDir, "cert.pem"),
+			ClientKey:          filepath.Join(tmpDir, "key.pem"),
+			InsecureSkipVerify: true,
+		}
+		writeClientKeyPair(tlsConfig.ClientCert, tlsConfig.ClientKey, "web.example.com")
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			TLS:      tlsConfig,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	JustBeforeEach(func() {
+		output, err = client.AuthCert("web")
+	})
+
+	It("should log in with the client certificate", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(presented).To(Equal("web.example.com"))
+		Expect(login).To(Equal(map[string]string{"name": "web"}))
+		Expect(client.AuthToken).To(Equal("s.cert"))
+		Expect(output.Metadata).To(Equal(vaultkv.AuthCertMetadata{
+			CertName:     "web",
+			CommonName:   "web.example.com",
+			SerialNumber: "3",
+		}))
+	})
+
+	When("no client certificate is configured", func() {
+		BeforeEach(func() {
+			client.TLS = nil
+		})
+
+		It("should err without making a request", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(login).To(BeNil())
+		})
+	})
+
+	When("the metadata does not fit AuthCertMetadata", func() {
+		BeforeEach(func() {
+			loginBody = `{"auth":{"client_token":"s.cert","metadata":{"cert_name":"web","serial_number":3}}}`
+		})
+
+		It("should err rather than panic", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(client.AuthToken).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,459 @@
+package vaultkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//AuthMethod is a way of getting a token for a Client. Login authenticates
+// against the Vault with the given Client, sets the token of the Client to the
+// one obtained, and returns the AuthOutput of the login. AuthMethods can be
+// given as the AuthMethod member of a Client to log in again when its token is
+// refused, as the Login function of a TokenWatcher, or combined with an
+// AuthChain.
+type AuthMethod interface {
+	Login(ctx context.Context, v *Client) (*AuthOutput, error)
+}
+
+//AuthMethodFunc allows an ordinary function to be used as an AuthMethod.
+type AuthMethodFunc func(ctx context.Context, v *Client) (*AuthOutput, error)
+
+//Login calls f(ctx, v).
+func (f AuthMethodFunc) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return f(ctx, v)
+}
+
+//AuthChain is an AuthMethod which tries each of its AuthMethods in order, and
+// uses the first to succeed. This allows the credentials of a service to be
+// configured by whatever is present in its environment, such as a token in
+// the environment or a token file, and failing that, AppRole credentials from
+// files or the service account of a Kubernetes pod.
+type AuthChain []AuthMethod
+
+//Login tries each of the AuthMethods in the chain in turn, and returns the
+// output of the first to succeed. If none do, an error listing the failures of
+// each is returned.
+func (c AuthChain) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	if len(c) == 0 {
+		return nil, fmt.Errorf("no auth methods given")
+	}
+
+	ctx = withoutReauth(ctx)
+	failures := make([]string, 0, len(c))
+	for _, method := range c {
+		ret, err := method.Login(ctx, v)
+		if err == nil {
+			return ret, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		failures = append(failures, err.Error())
+	}
+
+	return nil, fmt.Errorf("All auth methods failed: %s", strings.Join(failures, "; "))
+}
+
+//TokenAuth is an AuthMethod which uses the given token, if Vault accepts it.
+type TokenAuth struct {
+	Token string
+}
+
+//Login checks that the token is valid by looking it up, and if it is, sets it
+// as the token of the Client.
+func (a TokenAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	if a.Token == "" {
+		return nil, fmt.Errorf("no token given")
+	}
+
+	return loginWithToken(ctx, v, a.Token)
+}
+
+//EnvTokenAuth is an AuthMethod which uses the token in an environment
+// variable, if it is set and Vault accepts it.
+type EnvTokenAuth struct {
+	//Variable is the name of the environment variable. If empty, VAULT_TOKEN is
+	// used.
+	Variable string
+}
+
+//Login reads the token from the environment, checks that it is valid, and sets
+// it as the token of the Client.
+func (a EnvTokenAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	variable := a.Variable
+	if variable == "" {
+		variable = "VAULT_TOKEN"
+	}
+
+	token := os.Getenv(variable)
+	if token == "" {
+		return nil, fmt.Errorf("%s is not set", variable)
+	}
+
+	return loginWithToken(ctx, v, token)
+}
+
+//TokenFileAuth is an AuthMethod which uses the token in a file, such as one
+// written by a Vault Agent sink, if Vault accepts it. The file is read anew
+// for each login, so that a token that has been replaced is picked up.
+type TokenFileAuth struct {
+	//Path is the path to the token file. If empty, ~/.vault-token is used.
+	Path string
+}
+
+//Login reads the token from the file, checks that it is valid, and sets it as
+// the token of the Client.
+func (a TokenFileAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	path := a.Path
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(home, ".vault-token")
+	}
+
+	token, err := readCredentialFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return loginWithToken(ctx, v, token)
+}
+
+//GithubAuth is an AuthMethod which logs in with AuthGithubMount.
+type GithubAuth struct {
+	//Mount is the mount of the github auth method. If empty, "github" is used.
+	Mount       string
+	AccessToken string
+}
+
+//Login calls AuthGithubMountContext with the settings of the GithubAuth.
+func (a GithubAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthGithubMountContext(withoutReauth(ctx), mountOr(a.Mount, "github"), a.AccessToken)
+}
+
+//OktaAuth is an AuthMethod which logs in with AuthOktaMount.
+type OktaAuth struct {
+	//Mount is the mount of the okta auth method. If empty, "okta" is used.
+	Mount    string
+	Username string
+	Password string
+}
+
+//Login calls AuthOktaMountContext with the settings of the OktaAuth.
+func (a OktaAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthOktaMountContext(withoutReauth(ctx), mountOr(a.Mount, "okta"), a.Username, a.Password)
+}
+
+//LDAPAuth is an AuthMethod which logs in with AuthLDAPMount.
+type LDAPAuth struct {
+	//Mount is the mount of the ldap auth method. If empty, "ldap" is used.
+	Mount    string
+	Username string
+	Password string
+}
+
+//Login calls AuthLDAPMountContext with the settings of the LDAPAuth.
+func (a LDAPAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthLDAPMountContext(withoutReauth(ctx), mountOr(a.Mount, "ldap"), a.Username, a.Password)
+}
+
+//UserpassAuth is an AuthMethod which logs in with AuthUserpassMount.
+type UserpassAuth struct {
+	//Mount is the mount of the userpass auth method. If empty, "userpass" is
+	// used.
+	Mount    string
+	Username string
+	Password string
+}
+
+//Login calls AuthUserpassMountContext with the settings of the UserpassAuth.
+func (a UserpassAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthUserpassMountContext(withoutReauth(ctx), mountOr(a.Mount, "userpass"), a.Username, a.Password)
+}
+
+//ApproleAuth is an AuthMethod which logs in with AuthApproleMount.
+type ApproleAuth struct {
+	//Mount is the mount of the approle auth method. If empty, "approle" is used.
+	Mount    string
+	RoleID   string
+	SecretID string
+}
+
+//Login calls AuthApproleMountContext with the settings of the ApproleAuth.
+func (a ApproleAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthApproleMountContext(withoutReauth(ctx), mountOr(a.Mount, "approle"), a.RoleID, a.SecretID)
+}
+
+//ApproleFileAuth is an AuthMethod which logs in with AuthApproleMount, using a
+// role ID and secret ID read from files, as a Vault Agent does. The files are
+// read anew for each login, so that a rotated secret ID is picked up.
+type ApproleFileAuth struct {
+	//Mount is the mount of the approle auth method. If empty, "approle" is used.
+	Mount string
+	//RoleIDFile is the path to a file containing the role ID
+	RoleIDFile string
+	//SecretIDFile is the path to a file containing the secret ID. If empty, no
+	// secret ID is sent, for roles which do not require one.
+	SecretIDFile string
+}
+
+//Login reads the role ID and secret ID from their files, and calls
+// AuthApproleMountContext with them.
+func (a ApproleFileAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	roleID, err := readCredentialFile(a.RoleIDFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretID string
+	if a.SecretIDFile != "" {
+		secretID, err = readCredentialFile(a.SecretIDFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ApproleAuth{Mount: a.Mount, RoleID: roleID, SecretID: secretID}.Login(ctx, v)
+}
+
+//KubernetesAuth is an AuthMethod which logs in with AuthKubernetesMount, using
+// the service account token of the pod that this is running in.
+type KubernetesAuth struct {
+	//Mount is the mount of the kubernetes auth method. If empty, "kubernetes"
+	// is used.
+	Mount string
+	Role  string
+	//TokenPath is the path to the service account token. If empty,
+	// DefaultKubernetesTokenPath is used.
+	TokenPath string
+}
+
+//Login calls AuthKubernetesServiceAccountContext with the settings of the
+// KubernetesAuth.
+func (a KubernetesAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthKubernetesServiceAccountContext(withoutReauth(ctx), mountOr(a.Mount, "kubernetes"), a.Role, a.TokenPath)
+}
+
+//CertAuth is an AuthMethod which logs in with AuthCertMount, using the client
+// certificate of the Client.
+type CertAuth struct {
+	//Mount is the mount of the cert auth method. If empty, "cert" is used.
+	Mount string
+	//Name is the certificate role to log in as. If empty, every role is tried.
+	Name string
+}
+
+//Login calls AuthCertMountContext with the settings of the CertAuth.
+func (a CertAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthCertMountContext(withoutReauth(ctx), mountOr(a.Mount, "cert"), a.Name)
+}
+
+//JWTAuth is an AuthMethod which logs in with AuthJWTMount.
+type JWTAuth struct {
+	//Mount is the mount of the jwt auth method. If empty, "jwt" is used.
+	Mount string
+	Role  string
+	//JWT is the token to log in with. If empty, it is read from JWTPath.
+	JWT string
+	//JWTPath is the path to a file containing the token to log in with, which
+	// is read anew for each login, so that a token that has been replaced is
+	// picked up.
+	JWTPath string
+}
+
+//Login calls AuthJWTMountContext with the settings of the JWTAuth.
+func (a JWTAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	jwt := a.JWT
+	if jwt == "" {
+		var err error
+		jwt, err = readCredentialFile(a.JWTPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return v.AuthJWTMountContext(withoutReauth(ctx), mountOr(a.Mount, "jwt"), a.Role, jwt)
+}
+
+//AWSIAMAuth is an AuthMethod which logs in with AuthAWSIAMMount.
+type AWSIAMAuth struct {
+	//Mount is the mount of the aws auth method. If empty, "aws" is used.
+	Mount   string
+	Role    string
+	Options AWSIAMLoginOptions
+}
+
+//Login calls AuthAWSIAMMountContext with the settings of the AWSIAMAuth.
+func (a AWSIAMAuth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	return v.AuthAWSIAMMountContext(withoutReauth(ctx), mountOr(a.Mount, "aws"), a.Role, a.Options)
+}
+
+//AWSEC2Auth is an AuthMethod which logs in with AuthAWSEC2Mount, using the
+// identity document of the EC2 instance that this is running on. It must be
+// used as a pointer, as it keeps the nonce that Vault generates on the first
+// login for those that follow.
+type AWSEC2Auth struct {
+	//Mount is the mount of the aws auth method. If empty, "aws" is used.
+	Mount string
+	Role  string
+	//Nonce is given with each login. If empty, it is set to the one that Vault
+	// returns from the first login.
+	Nonce string
+}
+
+//Login gets the PKCS7 signature of the identity document of the instance with
+// AWSEC2IdentityPKCS7, and calls AuthAWSEC2MountContext with it.
+func (a *AWSEC2Auth) Login(ctx context.Context, v *Client) (*AuthOutput, error) {
+	pkcs7, err := AWSEC2IdentityPKCS7(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := v.AuthAWSEC2MountContext(withoutReauth(ctx), mountOr(a.Mount, "aws"), a.Role, pkcs7, a.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata, ok := ret.Metadata.(AuthAWSMetadata); ok && a.Nonce == "" {
+		a.Nonce = metadata.Nonce
+	}
+
+	return ret, nil
+}
+
+func mountOr(mount, def string) string {
+	if mount == "" {
+		return def
+	}
+
+	return mount
+}
+
+//readCredentialFile returns the contents of the file at the given path, with
+// surrounding whitespace removed. It is an error for the file to be empty.
+func readCredentialFile(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("no file given")
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	ret := strings.TrimSpace(string(contents))
+	if ret == "" {
+		return "", fmt.Errorf("`%s' is empty", path)
+	}
+
+	return ret, nil
+}
+
+//loginWithToken looks up the given token, and if it is valid, sets it as the
+// token of the Client and returns what was found out about it.
+func loginWithToken(ctx context.Context, v *Client, token string) (*AuthOutput, error) {
+	info, err := v.WithToken(token).TokenInfoSelfContext(withoutReauth(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	v.SetAuthToken(token)
+	return &AuthOutput{
+		Renewable:     info.Renewable,
+		LeaseDuration: info.TTL,
+		ClientToken:   token,
+		Accessor:      info.Accessor,
+		Policies:      info.Policies,
+	}, nil
+}
+
+type reauthContextKey struct{}
+
+//withoutReauth returns a context for requests which must not cause a new login
+// if they are refused, such as those made while logging in.
+func withoutReauth(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reauthContextKey{}, struct{}{})
+}
+
+//canReauth returns true if a request made with the given context may cause a
+// login with the AuthMethod of the Client.
+func (v *Client) canReauth(ctx context.Context) bool {
+	return v.AuthMethod != nil && ctx.Value(reauthContextKey{}) == nil
+}
+
+//shouldReauth returns true if a request to the given path made with the given
+// token and context which got the given response may be made again after
+// logging in with the AuthMethod of the Client, if tokenRefused confirms that
+// the token is no longer valid.
+func (v *Client) shouldReauth(ctx context.Context, path, token string, resp *http.Response) bool {
+	return v.canReauth(ctx) &&
+		token != "" &&
+		resp.StatusCode == http.StatusForbidden &&
+		!isLoginPath(path)
+}
+
+//isLoginPath returns true if the given path is that of a login to an auth
+// method, such as auth/approle/login or auth/userpass/login/bob. Their 403s
+// are for the credentials given, not for the token of the Client.
+func isLoginPath(path string) bool {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "auth" {
+		return false
+	}
+
+	for _, part := range parts[2:] {
+		if part == "login" {
+			return true
+		}
+	}
+
+	return false
+}
+
+//tokenRefused returns true if the given token was refused for being invalid,
+// rather than for lacking the policies for the request, given the body of the
+// 403 that it got. Vault only says which in some versions, so otherwise the
+// token is looked up, which any valid token may do under the default policy.
+func (v *Client) tokenRefused(ctx context.Context, token string, body []byte) bool {
+	if bytes.Contains(body, []byte("invalid token")) {
+		return true
+	}
+
+	_, err := v.WithToken(token).TokenInfoSelfContext(withoutReauth(withoutWrapping(ctx)))
+	return IsForbidden(err)
+}
+
+//reauth logs in with the AuthMethod of the Client, unless the token has already
+// been replaced since staleToken was refused, such as by a concurrent request.
+func (v *Client) reauth(ctx context.Context, staleToken string) error {
+	v.reauthLock.Lock()
+	defer v.reauthLock.Unlock()
+
+	if v.authToken() != staleToken {
+		return nil
+	}
+
+	//The login must not be wrapped if the request that was refused was, or the
+	// new token would be handed back in place of the response asked for
+	out, err := v.AuthMethod.Login(withoutReauth(withoutWrapping(ctx)), v)
+	if err != nil {
+		return err
+	}
+
+	if out != nil && out.ClientToken != "" {
+		v.SetAuthToken(out.ClientToken)
+	}
+
+	return nil
+}
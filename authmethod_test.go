@@ -0,0 +1,365 @@
+package vaultkv_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthMethod", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var dir string
+
+	//The state of the fake Vault
+	var lock sync.Mutex
+	var validToken string
+	var logins int
+
+	BeforeEach(func() {
+		validToken = "s.valid"
+		logins = 0
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+
+			if r.Header.Get("X-Vault-Wrap-TTL") != "" && (r.URL.Path == "/v1/auth/approle/login" || r.Header.Get("X-Vault-Token") == validToken) {
+				_, _ = fmt.Fprintf(w, `{"wrap_info":{"token":"s.wrapping","ttl":60,"creation_path":%q}}`, strings.TrimPrefix(r.URL.Path, "/v1/"))
+				return
+			}
+
+			if r.URL.Path == "/v1/auth/approle/login" {
+				creds := map[string]string{}
+				Expect(json.NewDecoder(r.Body).Decode(&creds)).To(Succeed())
+				if creds["role_id"] != "my-role" || creds["secret_id"] != "my-secret" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"errors":["invalid secret id"]}`))
+					return
+				}
+
+				logins++
+				validToken = fmt.Sprintf("s.login%d", logins)
+				_, _ = fmt.Fprintf(w, `{"auth":{"client_token":%q,"lease_duration":60,"renewable":true}}`, validToken)
+				return
+			}
+
+			if r.URL.Path == "/v1/auth/kubernetes/login" {
+				creds := map[string]string{}
+				Expect(json.NewDecoder(r.Body).Decode(&creds)).To(Succeed())
+				if creds["role"] != "web" || creds["jwt"] != "eyJ.sa.jwt" {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+					return
+				}
+
+				logins++
+				validToken = fmt.Sprintf("s.login%d", logins)
+				_, _ = fmt.Fprintf(w, `{"auth":{"client_token":%q,"lease_duration":60,"metadata":{"role":"web","service_account_name":"web","service_account_namespace":"prod","service_account_uid":"1234"}}}`, validToken)
+				return
+			}
+
+			if r.Header.Get("X-Vault-Token") != validToken {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+
+			switch r.URL.Path {
+			case "/v1/auth/token/lookup-self":
+				_, _ = w.Write([]byte(`{"data":{"ttl":60,"renewable":true,"accessor":"acc","policies":["default"]}}`))
+			case "/v1/secret/foo":
+				_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			case "/v1/secret/forbidden":
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+			}
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+
+		dir, err = ioutil.TempDir("", "vaultkv-test-auth")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(contents), 0600)).To(Succeed())
+		return path
+	}
+
+	Describe("AuthChain", func() {
+		var chain vaultkv.AuthChain
+		var output *vaultkv.AuthOutput
+
+		JustBeforeEach(func() {
+			output, err = chain.Login(context.Background(), client)
+		})
+
+		When("the earlier methods have no credentials", func() {
+			BeforeEach(func() {
+				chain = vaultkv.AuthChain{
+					vaultkv.EnvTokenAuth{Variable: "VAULTKV_TEST_UNSET_TOKEN"},
+					vaultkv.TokenFileAuth{Path: filepath.Join(dir, "missing")},
+					vaultkv.ApproleFileAuth{
+						RoleIDFile:   writeFile("role-id", "my-role\n"),
+						SecretIDFile: writeFile("secret-id", "my-secret\n"),
+					},
+				}
+			})
+
+			It("should log in with the first that has them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.ClientToken).To(Equal("s.login1"))
+				Expect(client.AuthToken).To(Equal("s.login1"))
+			})
+		})
+
+		When("a token file has a valid token", func() {
+			BeforeEach(func() {
+				chain = vaultkv.AuthChain{
+					vaultkv.TokenFileAuth{Path: writeFile("token", "s.valid\n")},
+					vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"},
+				}
+			})
+
+			It("should use the token without logging in", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(output.ClientToken).To(Equal("s.valid"))
+				Expect(output.Accessor).To(Equal("acc"))
+				Expect(output.Policies).To(Equal([]string{"default"}))
+				Expect(client.AuthToken).To(Equal("s.valid"))
+				Expect(logins).To(Equal(0))
+			})
+		})
+
+		When("a token file has an invalid token", func() {
+			BeforeEach(func() {
+				client.AuthToken = "s.before"
+				chain = vaultkv.AuthChain{
+					vaultkv.TokenFileAuth{Path: writeFile("token", "s.invalid")},
+				}
+			})
+
+			It("should fail and leave the token of the client alone", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(client.AuthToken).To(Equal("s.before"))
+			})
+		})
+
+		When("every method fails", func() {
+			BeforeEach(func() {
+				chain = vaultkv.AuthChain{
+					vaultkv.TokenAuth{},
+					vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "wrong"},
+				}
+			})
+
+			It("should return an error listing each failure", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no token given"))
+				Expect(err.Error()).To(ContainSubstring("invalid secret id"))
+			})
+		})
+	})
+
+	Describe("KubernetesAuth", func() {
+		var method vaultkv.KubernetesAuth
+		var output *vaultkv.AuthOutput
+
+		BeforeEach(func() {
+			method = vaultkv.KubernetesAuth{
+				Role:      "web",
+				TokenPath: writeFile("token", "eyJ.sa.jwt\n"),
+			}
+		})
+
+		JustBeforeEach(func() {
+			output, err = method.Login(context.Background(), client)
+		})
+
+		It("should log in with the service account token", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.AuthToken).To(Equal("s.login1"))
+			Expect(output.Metadata).To(Equal(vaultkv.AuthKubernetesMetadata{
+				Role:                    "web",
+				ServiceAccountName:      "web",
+				ServiceAccountNamespace: "prod",
+				ServiceAccountUID:       "1234",
+			}))
+		})
+
+		When("the token file does not exist", func() {
+			BeforeEach(func() {
+				method.TokenPath = filepath.Join(dir, "missing")
+			})
+
+			It("should err without logging in", func() {
+				Expect(err).To(HaveOccurred())
+				Expect(logins).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Re-login on 403", func() {
+		var output map[string]string
+
+		BeforeEach(func() {
+			output = nil
+			client.AuthToken = "s.expired"
+			client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"}
+		})
+
+		JustBeforeEach(func() {
+			_, err = client.Do("GET", "secret/foo", nil, &output)
+		})
+
+		It("should log in again and retry the request", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(client.AuthToken).To(Equal("s.login1"))
+			Expect(logins).To(Equal(1))
+		})
+
+		When("the login fails", func() {
+			BeforeEach(func() {
+				client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "wrong"}
+			})
+
+			It("should return the error of the login", func() {
+				Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrBadRequest{}))
+				Expect(client.AuthToken).To(Equal("s.expired"))
+			})
+		})
+
+		When("the token is valid but its policies do not allow the request", func() {
+			BeforeEach(func() {
+				client.AuthToken = "s.valid"
+			})
+
+			JustBeforeEach(func() {
+				_, err = client.Do("GET", "secret/forbidden", nil, &output)
+			})
+
+			It("should return the 403 without logging in", func() {
+				Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrForbidden{}))
+				Expect(client.AuthToken).To(Equal("s.valid"))
+				Expect(logins).To(Equal(0))
+			})
+		})
+
+		When("the client was made with WithToken", func() {
+			BeforeEach(func() {
+				client = client.WithToken("s.other")
+			})
+
+			It("should not log in", func() {
+				Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrForbidden{}))
+				Expect(logins).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("A refused login", func() {
+		BeforeEach(func() {
+			client.AuthToken = "s.expired"
+			client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"}
+		})
+
+		JustBeforeEach(func() {
+			_, err = client.AuthKubernetes("web", "eyJ.wrong.jwt")
+		})
+
+		It("should return the 403 without logging in with the AuthMethod", func() {
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrForbidden{}))
+			Expect(client.AuthToken).To(Equal("s.expired"))
+			Expect(logins).To(Equal(0))
+		})
+	})
+
+	Describe("Re-login on 403 of a wrapped request", func() {
+		var info *vaultkv.WrapInfo
+
+		BeforeEach(func() {
+			client.AuthToken = "s.expired"
+			client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"}
+		})
+
+		JustBeforeEach(func() {
+			info, err = client.RequestWrapped(context.Background(), time.Minute, func(ctx context.Context) error {
+				return client.GetContext(ctx, "secret/foo", nil)
+			})
+		})
+
+		It("should log in without wrapping the login, and wrap the retried request", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.CreationPath).To(Equal("secret/foo"))
+			Expect(client.AuthToken).To(Equal("s.login1"))
+			Expect(logins).To(Equal(1))
+		})
+	})
+
+	Describe("Login before the first request", func() {
+		var output map[string]string
+
+		BeforeEach(func() {
+			client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"}
+		})
+
+		JustBeforeEach(func() {
+			_, err = client.Do("GET", "secret/foo", nil, &output)
+		})
+
+		It("should log in when the client has no token", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(client.AuthToken).To(Equal("s.login1"))
+			Expect(logins).To(Equal(1))
+		})
+	})
+
+	Describe("Unwrapping with a refused wrapping token", func() {
+		BeforeEach(func() {
+			client.AuthToken = "s.valid"
+			client.AuthMethod = vaultkv.ApproleAuth{RoleID: "my-role", SecretID: "my-secret"}
+		})
+
+		JustBeforeEach(func() {
+			err = client.Unwrap("s.used", &map[string]interface{}{})
+		})
+
+		It("should not log in and retry with the token of the client", func() {
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrForbidden{}))
+			Expect(logins).To(Equal(0))
+			Expect(client.AuthToken).To(Equal("s.valid"))
+		})
+	})
+})
@@ -0,0 +1,484 @@
+package vaultkv
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+//AWSCredentials are the credentials of an AWS IAM user or role, used to sign
+// requests to AWS.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	//SessionToken is given with temporary credentials, such as those of an
+	// assumed role or a Lambda function.
+	SessionToken string
+}
+
+//AWSCredentialsFromEnv returns the credentials given by the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, as they
+// are set for Lambda functions. An error is returned if the access key ID or
+// secret access key is unset.
+func AWSCredentialsFromEnv() (*AWSCredentials, error) {
+	ret := &AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+
+	if ret.AccessKeyID == "" || ret.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are not set")
+	}
+
+	return ret, nil
+}
+
+//AWSCredentialsFromSharedFile returns the credentials of the given profile in
+// the AWS shared credentials file at the given path. If path is empty, the
+// file given by AWS_SHARED_CREDENTIALS_FILE is used, or ~/.aws/credentials if
+// that is unset. If profile is empty, the profile given by AWS_PROFILE is
+// used, or "default" if that is unset.
+func AWSCredentialsFromSharedFile(path, profile string) (*AWSCredentials, error) {
+	if path == "" {
+		path = os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	if profile == "" {
+		profile = "default"
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &AWSCredentials{}
+	var section string
+	scanner := bufio.NewScanner(strings.NewReader(string(contents)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		if section != profile {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+		switch strings.ToLower(strings.TrimSpace(parts[0])) {
+		case "aws_access_key_id":
+			ret.AccessKeyID = value
+		case "aws_secret_access_key":
+			ret.SecretAccessKey = value
+		case "aws_session_token":
+			ret.SessionToken = value
+		}
+	}
+
+	if ret.AccessKeyID == "" || ret.SecretAccessKey == "" {
+		return nil, fmt.Errorf("No credentials for profile `%s' in `%s'", profile, path)
+	}
+
+	return ret, nil
+}
+
+//DefaultAWSCredentials returns the credentials from the environment if they
+// are set, and otherwise those from the shared credentials file. See
+// AWSCredentialsFromEnv and AWSCredentialsFromSharedFile.
+func DefaultAWSCredentials() (*AWSCredentials, error) {
+	ret, err := AWSCredentialsFromEnv()
+	if err == nil {
+		return ret, nil
+	}
+
+	ret, err = AWSCredentialsFromSharedFile("", "")
+	if err != nil {
+		return nil, fmt.Errorf("Could not find AWS credentials in the environment or the shared credentials file: %s", err)
+	}
+
+	return ret, nil
+}
+
+const awsTimeFormat = "20060102T150405Z"
+
+//Sign signs the given request with AWS Signature Version 4, for the given
+// region and service, as of the given time. The body must be that of the
+// request, which is not read. The X-Amz-Date header, the X-Amz-Security-Token
+// header if there is a SessionToken, and the Authorization header are set on
+// the request. Every header of the request, along with its host, is signed.
+func (c AWSCredentials) Sign(req *http.Request, body []byte, region, service string, t time.Time) {
+	t = t.UTC()
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", t.Format(awsTimeFormat))
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		trimmed := make([]string, 0, len(values))
+		for _, value := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(value), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	date := t.Format("20060102")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		t.Format(awsTimeFormat),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + c.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+//awsCanonicalQuery encodes a query string as AWS signatures require, with its
+// parameters sorted and spaces encoded as %20.
+func awsCanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	escape := func(s string) string {
+		return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+	}
+
+	params := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, escape(key)+"="+escape(value))
+		}
+	}
+
+	return strings.Join(params, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+//AuthAWSMetadata is the metadata member set by AuthAWSIAM and AuthAWSEC2.
+// Which members are set depends on the method used to log in.
+type AuthAWSMetadata struct {
+	AccountID string `json:"account_id"`
+	AuthType  string `json:"auth_type"`
+	//Set by the iam method
+	CanonicalARN       string `json:"canonical_arn"`
+	ClientARN          string `json:"client_arn"`
+	ClientUserID       string `json:"client_user_id"`
+	InferredAWSRegion  string `json:"inferred_aws_region"`
+	InferredEntityID   string `json:"inferred_entity_id"`
+	InferredEntityType string `json:"inferred_entity_type"`
+	//Set by the ec2 method
+	AMIID         string `json:"ami_id"`
+	InstanceID    string `json:"instance_id"`
+	Nonce         string `json:"nonce"`
+	Region        string `json:"region"`
+	Role          string `json:"role"`
+	RoleTagMaxTTL string `json:"role_tag_max_ttl"`
+}
+
+//AWSIAMLoginOptions configure the signed request made by AuthAWSIAM.
+type AWSIAMLoginOptions struct {
+	//Credentials sign the request. If nil, DefaultAWSCredentials is used.
+	Credentials *AWSCredentials
+	//Region, if set, causes the STS endpoint of the region to be used in place
+	// of the global one. The auth mount must be configured with the same
+	// endpoint.
+	Region string
+	//ServerID, if set, is sent as the X-Vault-AWS-IAM-Server-ID header, which
+	// the auth mount can be configured to require, so that the signed request
+	// cannot be replayed against other services.
+	ServerID string
+}
+
+const awsSTSRequestBody = "Action=GetCallerIdentity&Version=2011-06-15"
+
+//AuthAWSIAM is a shorthand for AuthAWSIAMMount against the default aws
+// mountpoint, 'aws'.
+func (v *Client) AuthAWSIAM(role string, opts AWSIAMLoginOptions) (ret *AuthOutput, err error) {
+	return v.AuthAWSIAMMount("aws", role, opts)
+}
+
+//AuthAWSIAMContext is AuthAWSIAM with a context governing the request.
+func (v *Client) AuthAWSIAMContext(ctx context.Context, role string, opts AWSIAMLoginOptions) (ret *AuthOutput, err error) {
+	return v.AuthAWSIAMMountContext(ctx, "aws", role, opts)
+}
+
+//AuthAWSIAMMount logs in to the aws auth endpoint at the given mount with the
+// iam method, as the given role. A sts:GetCallerIdentity request is signed with
+// the AWS credentials, and given to the Vault, which sends it to AWS to find
+// out who signed it. No request is made to AWS by the client. If the login is
+// successful, the AuthOutput object is returned, with Metadata of type
+// AuthAWSMetadata, and this client's AuthToken is set to the returned token.
+// Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthAWSIAMMount(mount, role string, opts AWSIAMLoginOptions) (ret *AuthOutput, err error) {
+	return v.AuthAWSIAMMountContext(context.Background(), mount, role, opts)
+}
+
+//AuthAWSIAMMountContext is AuthAWSIAMMount with a context governing the
+//request.
+func (v *Client) AuthAWSIAMMountContext(ctx context.Context, mount, role string, opts AWSIAMLoginOptions) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	creds := opts.Credentials
+	if creds == nil {
+		creds, err = DefaultAWSCredentials()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	region, endpoint := "us-east-1", "https://sts.amazonaws.com/"
+	if opts.Region != "" {
+		region, endpoint = opts.Region, fmt.Sprintf("https://sts.%s.amazonaws.com/", opts.Region)
+	}
+
+	stsReq, err := http.NewRequest("POST", endpoint, strings.NewReader(awsSTSRequestBody))
+	if err != nil {
+		return nil, err
+	}
+	stsReq.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if opts.ServerID != "" {
+		stsReq.Header.Set("X-Vault-AWS-IAM-Server-ID", opts.ServerID)
+	}
+	creds.Sign(stsReq, []byte(awsSTSRequestBody), region, "sts", time.Now())
+
+	headers, err := json.Marshal(stsReq.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	err = v.doRequest(
+		ctx,
+		"POST",
+		fmt.Sprintf("/auth/%s/login", mount),
+		struct {
+			Role           string `json:"role,omitempty"`
+			Method         string `json:"iam_http_request_method"`
+			URL            string `json:"iam_request_url"`
+			Body           string `json:"iam_request_body"`
+			RequestHeaders string `json:"iam_request_headers"`
+		}{
+			Role:           role,
+			Method:         stsReq.Method,
+			URL:            base64.StdEncoding.EncodeToString([]byte(endpoint)),
+			Body:           base64.StdEncoding.EncodeToString([]byte(awsSTSRequestBody)),
+			RequestHeaders: base64.StdEncoding.EncodeToString(headers),
+		},
+		&raw,
+	)
+	if err != nil {
+		return
+	}
+
+	ret, err = raw.toFinal(AuthAWSMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
+
+	return
+}
+
+//AuthAWSEC2 is a shorthand for AuthAWSEC2Mount against the default aws
+// mountpoint, 'aws'.
+func (v *Client) AuthAWSEC2(role, pkcs7, nonce string) (ret *AuthOutput, err error) {
+	return v.AuthAWSEC2Mount("aws", role, pkcs7, nonce)
+}
+
+//AuthAWSEC2Context is AuthAWSEC2 with a context governing the request.
+func (v *Client) AuthAWSEC2Context(ctx context.Context, role, pkcs7, nonce string) (ret *AuthOutput, err error) {
+	return v.AuthAWSEC2MountContext(ctx, "aws", role, pkcs7, nonce)
+}
+
+//AuthAWSEC2Mount logs in to the aws auth endpoint at the given mount with the
+// ec2 method, as the given role, using the PKCS7 signature of the identity
+// document of the EC2 instance, as returned by AWSEC2IdentityPKCS7. The first
+// login of an instance may be made without a nonce, in which case Vault
+// generates one and returns it in the Nonce of the metadata. That nonce must
+// be kept and given to every later login from the instance. If the login is
+// successful, the AuthOutput object is returned, with Metadata of type
+// AuthAWSMetadata, and this client's AuthToken is set to the returned token.
+// Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthAWSEC2Mount(mount, role, pkcs7, nonce string) (ret *AuthOutput, err error) {
+	return v.AuthAWSEC2MountContext(context.Background(), mount, role, pkcs7, nonce)
+}
+
+//AuthAWSEC2MountContext is AuthAWSEC2Mount with a context governing the
+//request.
+func (v *Client) AuthAWSEC2MountContext(ctx context.Context, mount, role, pkcs7, nonce string) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	err = v.doRequest(
+		ctx,
+		"POST",
+		fmt.Sprintf("/auth/%s/login", mount),
+		struct {
+			Role  string `json:"role,omitempty"`
+			PKCS7 string `json:"pkcs7"`
+			Nonce string `json:"nonce,omitempty"`
+		}{
+			Role:  role,
+			PKCS7: strings.Replace(pkcs7, "\n", "", -1),
+			Nonce: nonce,
+		},
+		&raw,
+	)
+	if err != nil {
+		return
+	}
+
+	ret, err = raw.toFinal(AuthAWSMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
+
+	return
+}
+
+const awsMetadataURL = "http://169.254.169.254/latest"
+
+//AWSEC2IdentityPKCS7 returns the PKCS7 signature of the identity document of
+// the EC2 instance that this is running on, from the instance metadata
+// service, for use with AuthAWSEC2.
+func AWSEC2IdentityPKCS7(ctx context.Context) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	//IMDSv2 requires a session token, which is asked for with a PUT
+	req, err := http.NewRequestWithContext(ctx, "PUT", awsMetadataURL+"/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+
+	token, err := awsMetadataDo(client, req)
+	if err != nil {
+		return "", err
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "GET", awsMetadataURL+"/dynamic/instance-identity/pkcs7", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+
+	return awsMetadataDo(client, req)
+}
+
+func awsMetadataDo(client *http.Client, req *http.Request) (string, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &ErrTransport{message: err.Error(), err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("EC2 instance metadata service returned %d for %s", resp.StatusCode, req.URL.Path)
+	}
+
+	return strings.TrimSpace(string(body)), nil
+}
@@ -0,0 +1,201 @@
+package vaultkv_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AWS", func() {
+	Describe("AWSCredentials.Sign", func() {
+		//These are test vectors from the AWS Signature Version 4 documentation
+		var creds = vaultkv.AWSCredentials{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		}
+		var signedAt = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		var req *http.Request
+
+		When("signing a plain GET", func() {
+			BeforeEach(func() {
+				req, err = http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+				Expect(err).NotTo(HaveOccurred())
+				creds.Sign(req, nil, "us-east-1", "service", signedAt)
+			})
+
+			It("should give the expected signature", func() {
+				Expect(req.Header.Get("X-Amz-Date")).To(Equal("20150830T123600Z"))
+				Expect(req.Header.Get("Authorization")).To(Equal(
+					"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+						"SignedHeaders=host;x-amz-date, " +
+						"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+				))
+			})
+		})
+
+		When("signing a request with a query and a content type", func() {
+			BeforeEach(func() {
+				req, err = http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+				creds.Sign(req, nil, "us-east-1", "iam", signedAt)
+			})
+
+			It("should give the expected signature", func() {
+				Expect(req.Header.Get("Authorization")).To(Equal(
+					"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+						"SignedHeaders=content-type;host;x-amz-date, " +
+						"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+				))
+			})
+		})
+	})
+
+	Describe("AWSCredentialsFromSharedFile", func() {
+		var dir string
+		var creds *vaultkv.AWSCredentials
+		var profile string
+
+		BeforeEach(func() {
+			profile = ""
+			dir, err = ioutil.TempDir("", "vaultkv-test-aws")
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(dir, "credentials"), []byte(strings.Join([]string{
+				"# a comment",
+				"[default]",
+				"aws_access_key_id = AKIDDEFAULT",
+				"aws_secret_access_key = defaultsecret",
+				"",
+				"[ci]",
+				"aws_access_key_id=AKIDCI",
+				"aws_secret_access_key=cisecret",
+				"aws_session_token=citoken",
+			}, "\n")), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		JustBeforeEach(func() {
+			creds, err = vaultkv.AWSCredentialsFromSharedFile(filepath.Join(dir, "credentials"), profile)
+		})
+
+		It("should read the default profile", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*creds).To(Equal(vaultkv.AWSCredentials{
+				AccessKeyID:     "AKIDDEFAULT",
+				SecretAccessKey: "defaultsecret",
+			}))
+		})
+
+		When("a profile is given", func() {
+			BeforeEach(func() {
+				profile = "ci"
+			})
+
+			It("should read that profile", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*creds).To(Equal(vaultkv.AWSCredentials{
+					AccessKeyID:     "AKIDCI",
+					SecretAccessKey: "cisecret",
+					SessionToken:    "citoken",
+				}))
+			})
+		})
+
+		When("the profile does not exist", func() {
+			BeforeEach(func() {
+				profile = "nope"
+			})
+
+			It("should err", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("AuthAWSIAM", func() {
+		var server *httptest.Server
+		var client *vaultkv.Client
+		var login map[string]string
+		var output *vaultkv.AuthOutput
+
+		BeforeEach(func() {
+			login = nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				Expect(r.URL.Path).To(Equal("/v1/auth/aws/login"))
+				Expect(json.NewDecoder(r.Body).Decode(&login)).To(Succeed())
+				_, _ = w.Write([]byte(`{"auth":{"client_token":"s.aws","metadata":{"account_id":"123456789012","auth_type":"iam","client_arn":"arn:aws:iam::123456789012:user/ci"}}}`))
+			}))
+
+			serverURL, err := url.Parse(server.URL)
+			Expect(err).NotTo(HaveOccurred())
+
+			client = &vaultkv.Client{
+				VaultURL: serverURL,
+				Trace:    GinkgoWriter,
+			}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		JustBeforeEach(func() {
+			output, err = client.AuthAWSIAM("ci", vaultkv.AWSIAMLoginOptions{
+				Credentials: &vaultkv.AWSCredentials{
+					AccessKeyID:     "AKIDEXAMPLE",
+					SecretAccessKey: "secret",
+					SessionToken:    "session",
+				},
+				ServerID: "vault.example.com",
+			})
+		})
+
+		decode := func(s string) string {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			Expect(err).NotTo(HaveOccurred())
+			return string(decoded)
+		}
+
+		It("should send a signed GetCallerIdentity request", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(login["role"]).To(Equal("ci"))
+			Expect(login["iam_http_request_method"]).To(Equal("POST"))
+			Expect(decode(login["iam_request_url"])).To(Equal("https://sts.amazonaws.com/"))
+			Expect(decode(login["iam_request_body"])).To(Equal("Action=GetCallerIdentity&Version=2011-06-15"))
+
+			headers := http.Header{}
+			Expect(json.Unmarshal([]byte(decode(login["iam_request_headers"])), &headers)).To(Succeed())
+			Expect(headers.Get("X-Vault-AWS-IAM-Server-ID")).To(Equal("vault.example.com"))
+			Expect(headers.Get("X-Amz-Security-Token")).To(Equal("session"))
+			Expect(headers.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"))
+			Expect(headers.Get("Authorization")).To(ContainSubstring("/us-east-1/sts/aws4_request"))
+			Expect(headers.Get("Authorization")).To(ContainSubstring("x-vault-aws-iam-server-id"))
+		})
+
+		It("should set the token and return the metadata", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.AuthToken).To(Equal("s.aws"))
+			Expect(output.Metadata).To(Equal(vaultkv.AuthAWSMetadata{
+				AccountID: "123456789012",
+				AuthType:  "iam",
+				ClientARN: "arn:aws:iam::123456789012:user/ci",
+			}))
+		})
+	})
+})
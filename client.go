@@ -4,15 +4,17 @@ package vaultkv
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 //Client provides functions that access and abstract the Vault API.
@@ -20,57 +22,155 @@ import (
 // 0.6.5 and above are tested to work with this client.
 type Client struct {
 	AuthToken string
-	VaultURL  *url.URL
-	//If Client is nil, http.DefaultClient will be used
+	//VaultURL is the address of the Vault. If it has no port, port 8200 is
+	// used, unless SchemeDefaultPort is set. A URL of the form
+	// unix:///path/to/socket addresses a Vault, or a Vault Agent, listening on a
+	// unix socket.
+	VaultURL *url.URL
+	//SchemeDefaultPort, if true, causes a VaultURL with no port to use the
+	// default port of its scheme, such as 443 for https, instead of 8200.
+	SchemeDefaultPort bool
+	//If Client is nil, an HTTP client using the TLS, Proxy, and unix socket
+	// settings of this Client is built, or http.DefaultClient is used if there
+	// are no such settings. A non-nil Client must use a transport that
+	// honors these settings itself, such as one from the Transport method.
 	Client *http.Client
+	//TLS configures the TLS settings of the HTTP client that is built when
+	// Client is nil. It has no effect if Client is non-nil.
+	TLS *TLSConfig
+	//Proxy, if non-nil, is the URL of the HTTP proxy which requests are sent
+	// through, in place of any given by the HTTP_PROXY, HTTPS_PROXY, and
+	// NO_PROXY environment variables. Like TLS, it has no effect if Client is
+	// non-nil.
+	Proxy *url.URL
 	//If Trace is non-nil, information about HTTP requests will be given into the
 	//Writer.
 	Trace io.Writer
+	//TraceRedaction configures which secrets are hidden from the Trace output.
+	// If nil, DefaultTraceRedaction is used.
+	TraceRedaction *TraceRedaction
 	//Namespace, if non-empty, will send a X-Vault-Namespace header on requests with
 	// the given value.
 	Namespace string
-	tokenLock sync.RWMutex
+	//Middleware wraps the sending of every HTTP request made by the Client, in
+	// the order given, such that the first is outermost. See Middleware.
+	Middleware []Middleware
+	//Limiter, if non-nil, caps the rate and concurrency of the requests made by
+	// the Client. See Limiter.
+	Limiter *Limiter
+	//RetryPolicy, if non-nil, configures which failed requests are retried and
+	// how long to wait between attempts. See DefaultRetryPolicy.
+	RetryPolicy *RetryPolicy
+	//Cluster, if non-nil, is used to choose the node each request is sent to
+	// in place of VaultURL. See Cluster for details.
+	Cluster *Cluster
+	//WarningHandler, if non-nil, is called with the warnings of any successful
+	// response that has them. Vault warns of, for example, the use of deprecated
+	// paths and parameters which it ignored.
+	WarningHandler func(method, path string, warnings []string)
+	//AuthMethod, if non-nil, is used to log in again when a request made by one
+	// of the API functions of the Client is refused with a 403 because its token
+	// is no longer valid, after which the request is made once more with the new
+	// token. This allows a Client to recover from its token expiring or being
+	// revoked. A 403 for a token which is still valid, such as one whose
+	// policies do not allow the request, or for a login, is returned as it is.
+	// If the Client has no
+	// AuthToken, it is also used to log in before the first such request, so
+	// that the caller need not log in first. Requests made with Curl are not
+	// retried.
+	AuthMethod AuthMethod
+	tokenLock  sync.RWMutex
+	reauthLock sync.Mutex
+
+	builtClientLock sync.Mutex
+	builtClient     *http.Client
+	builtClientFor  transportKey
 }
 
 type vaultResponse struct {
 	Data interface{} `json:"data"`
-	//There's totally more to the response, but this is all I care about atm.
+	//The rest of the envelope is decoded by responseAPI. See Response.
 }
 
 //URL encoded values can be given as a *url.Values as "input" when performing
 // a GET call
 func (v *Client) doRequest(
+	ctx context.Context,
 	method, path string,
 	input interface{},
 	output interface{}) error {
 
 	var query url.Values
-	var body io.Reader
+	var body []byte
 	if input != nil {
 		if strings.ToUpper(method) == "GET" {
 			//Input has to be a url.Values
 			query = input.(url.Values)
 		} else {
-			body = &bytes.Buffer{}
-			err := json.NewEncoder(body.(*bytes.Buffer)).Encode(input)
+			var err error
+			body, err = json.Marshal(input)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	resp, err := v.Curl(method, path, query, body)
+	token := v.authToken()
+	if token == "" && v.canReauth(ctx) {
+		//A Client given an AuthMethod but no token logs in before its first request
+		if err := v.reauth(ctx, token); err != nil {
+			return err
+		}
+
+		token = v.authToken()
+	}
+
+	resp, err := v.sendWithRetries(ctx, method, path, query, body)
+	if err == nil && v.shouldReauth(ctx, path, token, resp) {
+		//The body is kept to be parsed if the token turns out to be valid, but
+		// the response is closed so that checking the token does not wait on it
+		// when the Client has a Limiter
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+		if v.tokenRefused(ctx, token, respBody) {
+			if err = v.reauth(ctx, token); err != nil {
+				return err
+			}
+
+			resp, err = v.sendWithRetries(ctx, method, path, query, body)
+		}
+	}
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode/100 != 2 {
-		return v.parseError(resp)
+		return v.parseError(ctx, resp)
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	v.handleWarnings(method, path, respBody)
+
+	if wrap := wrapStateFrom(ctx); wrap != nil && resp.StatusCode == 200 {
+		wrapped, err := wrap.record(respBody)
+		if err != nil {
+			return err
+		}
+
+		if wrapped {
+			return errResponseWrapped
+		}
 	}
 
 	if output != nil && resp.StatusCode == 200 {
-		err = json.NewDecoder(resp.Body).Decode(output)
+		err = json.Unmarshal(respBody, output)
 		if err != nil {
 			if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
 				return fmt.Errorf("Could not parse response body as JSON, and returned Content-Type is `%s'. Client may not be reaching Vault", contentType)
@@ -79,55 +179,99 @@ func (v *Client) doRequest(
 		}
 	}
 
-	_, err = ioutil.ReadAll(resp.Body)
+	return nil
+}
 
-	return err
+//sendWithRetries makes the request, retrying it as the RetryPolicy of the
+// Client allows.
+func (v *Client) sendWithRetries(ctx context.Context, method, path string, query url.Values, body []byte) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		//The body must be given anew for each attempt, as the last one consumed it
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		resp, err := v.CurlContext(ctx, method, path, query, bodyReader)
+		if !v.RetryPolicy.shouldRetry(ctx, method, attempt, resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+
+		if waitErr := v.RetryPolicy.wait(ctx, attempt, resp); waitErr != nil {
+			return nil, waitErr
+		}
+	}
 }
 
 //Curl takes the given path, prepends <VaultURL>/v1/ to it, and makes the request
 // with the remainder of the given parameters. Errors returned only reflect
 // transport errors, not HTTP semantic errors
 func (v *Client) Curl(method string, path string, urlQuery url.Values, body io.Reader) (*http.Response, error) {
+	return v.CurlContext(context.Background(), method, path, urlQuery, body)
+}
+
+//CurlContext is Curl, but the request is bound to the given context. If the
+// context is cancelled or its deadline passes before the response is received,
+// an ErrTransport is returned.
+func (v *Client) CurlContext(ctx context.Context, method string, path string, urlQuery url.Values, body io.Reader) (*http.Response, error) {
+	if v.Cluster != nil {
+		return v.Cluster.curl(ctx, v, method, path, urlQuery, body)
+	}
+
+	return v.curlNode(ctx, v.VaultURL, method, path, urlQuery, body)
+}
+
+//curlNode performs the work of Curl against the Vault node at the given base
+// URL.
+func (v *Client) curlNode(ctx context.Context, base *url.URL, method string, path string, urlQuery url.Values, body io.Reader) (*http.Response, error) {
 	//Setup URL
-	u := *v.VaultURL
+	u := *base
 	pathPrefix := strings.Trim(u.Path, "/")
+	if isUnixSocket(base) {
+		//The socket path is not part of the request path. Vault ignores the
+		// host, but one is needed for a valid HTTP request.
+		u = url.URL{Scheme: "http", Host: "localhost"}
+		pathPrefix = ""
+	}
 	if pathPrefix != "" {
 		pathPrefix = pathPrefix + "/"
 	}
 	u.Path = fmt.Sprintf("/%sv1/%s", pathPrefix, strings.Trim(path, "/"))
-	if u.Port() == "" {
+	if u.Port() == "" && !isUnixSocket(base) && !v.SchemeDefaultPort {
 		u.Host = fmt.Sprintf("%s:8200", u.Host)
 	}
 	u.RawQuery = urlQuery.Encode()
 
 	//Do the request
-	req, err := http.NewRequest(method, u.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
 	if err != nil {
 		return nil, err
 	}
-	if v.Trace != nil {
-		dump, _ := httputil.DumpRequest(req, true)
-		_, _ = v.Trace.Write([]byte(fmt.Sprintf("Request:\n%s\n", dump)))
-	}
-
-	v.tokenLock.RLock()
-	token := v.AuthToken
-	v.tokenLock.RUnlock()
+	token := v.authToken()
 	if token == "" {
 		token = "01234567-89ab-cdef-0123-456789abcdef"
 	}
 	req.Header.Set("X-Vault-Token", token)
 
-	if v.Namespace != "" && !pathNamespaceBlacklisted(path) {
-		req.Header.Set("X-Vault-Namespace", strings.Trim(v.Namespace, "/")+"/")
+	if wrap := wrapStateFrom(ctx); wrap != nil {
+		req.Header.Set("X-Vault-Wrap-TTL", strconv.Itoa(int(wrap.ttl/time.Second)))
 	}
 
-	client := v.Client
-	if client == nil {
-		client = http.DefaultClient
+	client, err := v.httpClient()
+	if err != nil {
+		return nil, err
 	}
 
 	if client.CheckRedirect == nil {
+		//The client may be shared with other Clients using other tokens, so the
+		// redirect policy for this token is set on a copy of it.
+		withRedirect := *client
+		client = &withRedirect
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			if len(via) > 10 {
 				return fmt.Errorf("Stopped after 10 redirects")
@@ -137,18 +281,95 @@ func (v *Client) Curl(method string, path string, urlQuery url.Values, body io.R
 		}
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		fmt.Println("transport err", err.Error())
-		return nil, &ErrTransport{message: err.Error()}
+	send := func(req *http.Request) (*http.Response, error) {
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, &ErrTransport{message: err.Error(), err: err}
+		}
+
+		return resp, nil
+	}
+
+	return v.chain(RequestInfo{Method: method, Path: path}, send)(req)
+}
+
+//clone returns a new Client with the same configuration as this one, which
+// shares its HTTP client and therefore its connections.
+func (v *Client) clone() *Client {
+	ret := &Client{
+		AuthToken:   v.authToken(),
+		VaultURL:    v.VaultURL,
+		Client:      v.Client,
+		TLS:         v.TLS,
+		Proxy:       v.Proxy,
+		Trace:       v.Trace,
+		Namespace:   v.Namespace,
+		RetryPolicy: v.RetryPolicy,
+		Cluster:     v.Cluster,
+
+		WarningHandler: v.WarningHandler,
+		TraceRedaction: v.TraceRedaction,
+		Middleware:     v.Middleware,
+		Limiter:        v.Limiter,
+		AuthMethod:     v.AuthMethod,
+
+		SchemeDefaultPort: v.SchemeDefaultPort,
 	}
 
-	if v.Trace != nil {
-		dump, _ := httputil.DumpResponse(resp, true)
-		_, _ = v.Trace.Write([]byte(fmt.Sprintf("Response:\n%s\n", dump)))
+	v.builtClientLock.Lock()
+	ret.builtClient, ret.builtClientFor = v.builtClient, v.builtClientFor
+	v.builtClientLock.Unlock()
+
+	return ret
+}
+
+//WithToken returns a copy of the Client which authenticates with the given
+// token. The copy shares the HTTP client, and therefore the connections, of
+// this Client, as well as its Cluster and Limiter, but changes to the token of
+// either do not affect the other. This allows requests to be made on behalf of
+// many tokens concurrently. The copy has no AuthMethod, so that it does not log
+// in as the identity of this Client if the token is refused.
+func (v *Client) WithToken(token string) *Client {
+	ret := v.clone()
+	ret.AuthToken = token
+	ret.AuthMethod = nil
+	return ret
+}
+
+//WithNamespace returns a copy of the Client which sends requests to the given
+// Vault Enterprise namespace, in the same manner as WithToken. An empty
+// namespace sends requests to the root namespace.
+func (v *Client) WithNamespace(namespace string) *Client {
+	ret := v.clone()
+	ret.Namespace = namespace
+	return ret
+}
+
+//httpClient returns the HTTP client that requests should be made with.
+func (v *Client) httpClient() (*http.Client, error) {
+	if v.Client != nil {
+		return v.Client, nil
+	}
+
+	key := v.transportKey()
+	if key == (transportKey{}) {
+		return http.DefaultClient, nil
+	}
+
+	v.builtClientLock.Lock()
+	defer v.builtClientLock.Unlock()
+	//Rebuild if the settings have been changed since the client was built
+	if v.builtClient == nil || v.builtClientFor != key {
+		transport, err := v.Transport()
+		if err != nil {
+			return nil, err
+		}
+
+		v.builtClient = &http.Client{Transport: transport}
+		v.builtClientFor = key
 	}
 
-	return resp, nil
+	return v.builtClient, nil
 }
 
 var namespaceBlacklisted []string = []string{
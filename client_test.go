@@ -0,0 +1,99 @@
+package vaultkv_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scoped clients", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var lock sync.Mutex
+	//seen maps the path of each request to the token and namespace it was sent with
+	var seen map[string][2]string
+
+	BeforeEach(func() {
+		seen = map[string][2]string{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			seen[strings.TrimPrefix(r.URL.Path, "/v1/")] = [2]string{
+				r.Header.Get("X-Vault-Token"),
+				r.Header.Get("X-Vault-Namespace"),
+			}
+			lock.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data":{}}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			AuthToken: "s.parent",
+			VaultURL:  serverURL,
+			Namespace: "parent",
+			Trace:     GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("WithToken", func() {
+		It("should send each child's token concurrently without changing the parent", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					child := client.WithToken(fmt.Sprintf("s.tenant%d", i))
+					Expect(child.Get(fmt.Sprintf("secret/tenant%d", i), nil)).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			for i := 0; i < 10; i++ {
+				Expect(seen[fmt.Sprintf("secret/tenant%d", i)]).To(Equal([2]string{fmt.Sprintf("s.tenant%d", i), "parent/"}))
+			}
+
+			Expect(client.Get("secret/parent", nil)).To(Succeed())
+			Expect(seen["secret/parent"]).To(Equal([2]string{"s.parent", "parent/"}))
+		})
+
+		It("should not be affected by later changes to the parent's token", func() {
+			child := client.WithToken("s.child")
+			client.SetAuthToken("s.newparent")
+			Expect(child.Get("secret/child", nil)).To(Succeed())
+			Expect(seen["secret/child"][0]).To(Equal("s.child"))
+		})
+	})
+
+	Describe("WithNamespace", func() {
+		It("should send the child's namespace and the parent's token", func() {
+			child := client.WithNamespace("tenant")
+			Expect(child.Get("secret/child", nil)).To(Succeed())
+			Expect(seen["secret/child"]).To(Equal([2]string{"s.parent", "tenant/"}))
+
+			Expect(client.Get("secret/parent", nil)).To(Succeed())
+			Expect(seen["secret/parent"]).To(Equal([2]string{"s.parent", "parent/"}))
+		})
+
+		When("the namespace is empty", func() {
+			It("should send no namespace", func() {
+				Expect(client.WithNamespace("").Get("secret/root", nil)).To(Succeed())
+				Expect(seen["secret/root"][1]).To(BeEmpty())
+			})
+		})
+	})
+})
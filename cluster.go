@@ -0,0 +1,301 @@
+package vaultkv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+//Cluster routes the requests of a Client between the nodes of a Vault cluster
+// which are not behind a load balancer. Set it as the Cluster member of a
+// Client to use it, in which case the Client's VaultURL is ignored.
+//
+//The nodes are probed with Health to find which is active and which are
+// performance standbys. Writes are sent to the active node. Reads are sent to
+// the active node as well, unless ReadFromPerfStandby is set and a performance
+// standby is available. If the node a request is sent to cannot be reached or
+// reports that it is sealed, the nodes are probed again and the request is
+// sent to the next best node, until every node has been tried.
+//
+//A Cluster should not be copied after first use, but may be shared between
+// Clients.
+type Cluster struct {
+	//Nodes are the addresses of every Vault node in the cluster
+	Nodes []*url.URL
+	//ReadFromPerfStandby, if true, sends GET requests to performance standby
+	// nodes when any are available. Performance standbys are only eventually
+	// consistent with the active node, so a read immediately after a write may
+	// not reflect that write.
+	ReadFromPerfStandby bool
+
+	lock         sync.Mutex
+	probed       bool
+	active       *url.URL
+	perfStandbys []*url.URL
+	nextRead     int
+}
+
+//NodeState is the state of a Vault node as observed by Cluster.Refresh
+type NodeState int
+
+const (
+	//NodeUnavailable nodes are sealed, uninitialized, disaster recovery
+	// secondaries, or could not be reached.
+	NodeUnavailable NodeState = iota
+	//NodeActive is the node which services writes for the cluster
+	NodeActive
+	//NodeStandby nodes forward all requests to the active node
+	NodeStandby
+	//NodePerfStandby nodes can service reads themselves
+	NodePerfStandby
+)
+
+func (n NodeState) String() string {
+	switch n {
+	case NodeActive:
+		return "active"
+	case NodeStandby:
+		return "standby"
+	case NodePerfStandby:
+		return "performance standby"
+	}
+
+	return "unavailable"
+}
+
+//NewCluster returns a Cluster for the nodes at the given addresses. An address
+// given more than once is only added once.
+func NewCluster(addrs ...string) (*Cluster, error) {
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no node addresses given")
+	}
+
+	ret := &Cluster{}
+	seen := map[string]bool{}
+	for _, addr := range addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse node address `%s': %s", addr, err)
+		}
+
+		if seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+
+		ret.Nodes = append(ret.Nodes, u)
+	}
+
+	return ret, nil
+}
+
+//Refresh probes every node in the cluster with Health, using the settings of
+// the given Client, such as its HTTP client and Middleware, and records which
+// node is active and which are performance standbys. The state of each node is
+// returned in the same order as Nodes.
+func (c *Cluster) Refresh(ctx context.Context, v *Client) []NodeState {
+	states := make([]NodeState, len(c.Nodes))
+	var wg sync.WaitGroup
+	for i := range c.Nodes {
+		if c.Nodes[i] == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			states[i] = probeNode(ctx, v, c.Nodes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.probed = true
+	c.active = nil
+	c.perfStandbys = nil
+	for i, state := range states {
+		switch state {
+		case NodeActive:
+			c.active = c.Nodes[i]
+		case NodePerfStandby:
+			c.perfStandbys = append(c.perfStandbys, c.Nodes[i])
+		}
+	}
+
+	return states
+}
+
+func probeNode(ctx context.Context, v *Client, node *url.URL) NodeState {
+	//The probe is sent as the given Client would send any other request, but
+	// to the given node, and without logging in if it has no token
+	probe := v.WithToken(v.authToken())
+	probe.VaultURL = node
+	probe.Cluster = nil
+
+	err := probe.HealthContext(ctx, false)
+	switch {
+	case err == nil:
+		return NodeActive
+	case IsErrStandby(err):
+		return NodeStandby
+	case IsErrPerfStandby(err):
+		return NodePerfStandby
+	}
+
+	return NodeUnavailable
+}
+
+//ActiveNode returns the address of the node that was active as of the last
+// probe, or nil if no node was active.
+func (c *Cluster) ActiveNode() *url.URL {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.active
+}
+
+//markDown forgets any role that the given node was thought to have, so that
+// the next request probes the cluster again.
+func (c *Cluster) markDown(node *url.URL) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.probed = false
+	if c.active == node {
+		c.active = nil
+	}
+
+	for i := range c.perfStandbys {
+		if c.perfStandbys[i] == node {
+			c.perfStandbys = append(c.perfStandbys[:i], c.perfStandbys[i+1:]...)
+			break
+		}
+	}
+}
+
+//candidates returns the nodes to send a request to, best first.
+func (c *Cluster) candidates(ctx context.Context, v *Client, read bool) []*url.URL {
+	c.lock.Lock()
+	probed := c.probed
+	c.lock.Unlock()
+	if !probed {
+		c.Refresh(ctx, v)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ret := make([]*url.URL, 0, len(c.Nodes))
+	if read && c.ReadFromPerfStandby && len(c.perfStandbys) > 0 {
+		c.nextRead = (c.nextRead + 1) % len(c.perfStandbys)
+		ret = append(ret, c.perfStandbys[c.nextRead:]...)
+		ret = append(ret, c.perfStandbys[:c.nextRead]...)
+	}
+
+	if c.active != nil {
+		ret = append(ret, c.active)
+	}
+
+	//Fall back to the remaining nodes in their given order. Standbys will
+	// forward the request to an active node if there is one that we could not
+	// reach ourselves.
+	for _, node := range c.Nodes {
+		if node == nil {
+			continue
+		}
+
+		found := false
+		for _, existing := range ret {
+			if existing == node {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			ret = append(ret, node)
+		}
+	}
+
+	return ret
+}
+
+func (c *Cluster) curl(ctx context.Context, v *Client, method string, path string, urlQuery url.Values, body io.Reader) (*http.Response, error) {
+	if len(c.Nodes) == 0 {
+		return nil, &ErrTransport{message: "no nodes configured in cluster"}
+	}
+
+	//The body must be kept around in case it needs to be sent to another node
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	tried := map[*url.URL]bool{}
+	for {
+		//A failed node causes the cluster to be probed again, so the candidates
+		// are recomputed to prefer any newly elected active node.
+		var node *url.URL
+		for _, candidate := range c.candidates(ctx, v, method == "GET" || method == "HEAD") {
+			if !tried[candidate] {
+				node = candidate
+				break
+			}
+		}
+
+		//Nodes may hold the same node more than once, or nil, so running out of
+		// candidates is what says that every node has been tried
+		if node == nil {
+			break
+		}
+		tried[node] = true
+
+		var bodyReader io.Reader
+		if payload != nil {
+			bodyReader = bytes.NewReader(payload)
+		}
+
+		resp, err = v.curlNode(ctx, node, method, path, urlQuery, bodyReader)
+		if ctx.Err() != nil || !nodeFailed(resp, err) {
+			break
+		}
+
+		//The response is closed before the cluster is probed again, as the
+		// probes would otherwise wait on its in-flight slot when the Client has a
+		// Limiter. Its body is kept in case no other node can be reached.
+		if resp != nil {
+			respBody, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+		}
+
+		c.markDown(node)
+	}
+
+	if len(tried) == 0 {
+		return nil, &ErrTransport{message: "no nodes configured in cluster"}
+	}
+
+	return resp, err
+}
+
+//nodeFailed returns true if the response shows that the node cannot service
+// requests, and so the request should be tried elsewhere.
+func nodeFailed(resp *http.Response, err error) bool {
+	if err != nil {
+		return IsTransport(err)
+	}
+
+	return resp.StatusCode == http.StatusServiceUnavailable
+}
@@ -0,0 +1,239 @@
+package vaultkv_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//fakeNode is a stand-in for a Vault node which reports the given health
+// status code and records which paths it has been asked for.
+type fakeNode struct {
+	server *httptest.Server
+	lock   sync.Mutex
+	health int
+	paths  []string
+}
+
+func newFakeNode(health int) *fakeNode {
+	ret := &fakeNode{health: health}
+	ret.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ret.lock.Lock()
+		defer ret.lock.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/sys/health") {
+			w.WriteHeader(ret.health)
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+
+		ret.paths = append(ret.paths, r.Method+" "+r.URL.Path)
+		if ret.health == http.StatusServiceUnavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"errors":["Vault is sealed"]}`))
+			return
+		}
+
+		if r.Method == "GET" {
+			_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return ret
+}
+
+func (f *fakeNode) setHealth(health int) {
+	f.lock.Lock()
+	f.health = health
+	f.lock.Unlock()
+}
+
+func (f *fakeNode) requests() []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]string{}, f.paths...)
+}
+
+var _ = Describe("Cluster", func() {
+	var sealed, active, perfStandby *fakeNode
+	var cluster *vaultkv.Cluster
+	var client *vaultkv.Client
+
+	BeforeEach(func() {
+		sealed = newFakeNode(http.StatusServiceUnavailable)
+		active = newFakeNode(http.StatusOK)
+		perfStandby = newFakeNode(473)
+
+		cluster, err = vaultkv.NewCluster(sealed.server.URL, active.server.URL, perfStandby.server.URL)
+		Expect(err).NotTo(HaveOccurred())
+		client = &vaultkv.Client{Cluster: cluster, Trace: GinkgoWriter}
+	})
+
+	AfterEach(func() {
+		sealed.server.Close()
+		active.server.Close()
+		perfStandby.server.Close()
+	})
+
+	Describe("Refresh", func() {
+		It("should classify each node", func() {
+			states := cluster.Refresh(context.Background(), client)
+			Expect(states).To(Equal([]vaultkv.NodeState{
+				vaultkv.NodeUnavailable,
+				vaultkv.NodeActive,
+				vaultkv.NodePerfStandby,
+			}))
+			Expect(cluster.ActiveNode()).To(Equal(cluster.Nodes[1]))
+		})
+	})
+
+	It("should send writes to the active node", func() {
+		err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(active.requests()).To(Equal([]string{"PUT /v1/secret/foo"}))
+		Expect(sealed.requests()).To(BeEmpty())
+		Expect(perfStandby.requests()).To(BeEmpty())
+	})
+
+	It("should send reads to the active node by default", func() {
+		err = client.Get("secret/foo", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(active.requests()).To(Equal([]string{"GET /v1/secret/foo"}))
+		Expect(perfStandby.requests()).To(BeEmpty())
+	})
+
+	When("reading from performance standbys is allowed", func() {
+		BeforeEach(func() {
+			cluster.ReadFromPerfStandby = true
+		})
+
+		It("should send reads to the performance standby", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perfStandby.requests()).To(Equal([]string{"GET /v1/secret/foo"}))
+			Expect(active.requests()).To(BeEmpty())
+		})
+	})
+
+	When("the active node becomes unreachable", func() {
+		BeforeEach(func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			active.server.Close()
+			perfStandby.setHealth(http.StatusOK)
+		})
+
+		It("should fail over to the newly active node", func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perfStandby.requests()).To(Equal([]string{"PUT /v1/secret/foo"}))
+			Expect(cluster.ActiveNode()).To(Equal(cluster.Nodes[2]))
+		})
+	})
+
+	When("the active node becomes sealed", func() {
+		BeforeEach(func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			active.setHealth(http.StatusServiceUnavailable)
+			perfStandby.setHealth(http.StatusOK)
+		})
+
+		It("should fail over to the newly active node", func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(perfStandby.requests()).To(Equal([]string{"PUT /v1/secret/foo"}))
+		})
+	})
+
+	When("the nodes are given without ports", func() {
+		var lock sync.Mutex
+		var probed []string
+
+		BeforeEach(func() {
+			probed = nil
+			cluster, err = vaultkv.NewCluster("https://vault-a.example", "https://vault-b.example")
+			Expect(err).NotTo(HaveOccurred())
+
+			client = &vaultkv.Client{
+				Cluster:           cluster,
+				SchemeDefaultPort: true,
+				Trace:             GinkgoWriter,
+				Middleware: []vaultkv.Middleware{
+					func(info vaultkv.RequestInfo, req *http.Request, next vaultkv.RoundTripFunc) (*http.Response, error) {
+						lock.Lock()
+						probed = append(probed, req.URL.Host)
+						lock.Unlock()
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Header:     http.Header{"Content-Type": []string{"application/json"}},
+							Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+							Request:    req,
+						}, nil
+					},
+				},
+			}
+		})
+
+		It("should probe them on the ports the client sends its other requests to", func() {
+			cluster.Refresh(context.Background(), client)
+			Expect(probed).To(ConsistOf("vault-a.example", "vault-b.example"))
+		})
+	})
+
+	When("every node is sealed", func() {
+		BeforeEach(func() {
+			active.setHealth(http.StatusServiceUnavailable)
+			perfStandby.setHealth(http.StatusServiceUnavailable)
+		})
+
+		It("should return ErrSealed", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(vaultkv.IsSealed(err)).To(BeTrue())
+		})
+
+		When("only one request may be in flight at once", func() {
+			BeforeEach(func() {
+				client.Limiter = vaultkv.NewLimiter(0, 0, 1)
+			})
+
+			It("should probe the nodes without waiting on the failed requests", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				err = client.GetContext(ctx, "secret/foo", nil)
+				Expect(vaultkv.IsSealed(err)).To(BeTrue())
+				Expect(ctx.Err()).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	When("a node is listed more than once", func() {
+		BeforeEach(func() {
+			cluster.Nodes = []*url.URL{cluster.Nodes[0], cluster.Nodes[0], nil}
+		})
+
+		It("should try it once and return ErrSealed", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(vaultkv.IsSealed(err)).To(BeTrue())
+			Expect(sealed.requests()).To(Equal([]string{"GET /v1/secret/foo"}))
+		})
+	})
+
+	Describe("NewCluster", func() {
+		It("should only add each address once", func() {
+			cluster, err = vaultkv.NewCluster(active.server.URL, perfStandby.server.URL, active.server.URL)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cluster.Nodes).To(HaveLen(2))
+		})
+	})
+})
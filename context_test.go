@@ -0,0 +1,72 @@
+package vaultkv_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Context", func() {
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		ctx, cancel = context.WithCancel(context.Background())
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	When("the context is live", func() {
+		It("should make the request", func() {
+			err = vault.SetContext(ctx, "secret/foo", map[string]string{"bar": "baz"})
+			Expect(err).NotTo(HaveOccurred())
+
+			output := map[string]string{}
+			_, err = vault.NewKV().GetContext(ctx, "secret/foo", &output, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"bar": "baz"}))
+		})
+	})
+
+	When("the context has been cancelled", func() {
+		BeforeEach(func() {
+			cancel()
+		})
+
+		Specify("calls should return ErrTransport wrapping context.Canceled", func() {
+			for _, call := range []func() error{
+				func() error { return vault.GetContext(ctx, "secret/foo", nil) },
+				func() error { return vault.HealthContext(ctx, true) },
+				func() error { _, err := vault.SealStatusContext(ctx); return err },
+				func() error { _, err := vault.NewKV().GetContext(ctx, "secret/foo", nil, nil); return err },
+				func() error { _, err := vault.TokenInfoSelfContext(ctx); return err },
+			} {
+				err = call()
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrTransport{}))
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			}
+		})
+	})
+
+	When("the context deadline has passed", func() {
+		BeforeEach(func() {
+			cancel()
+			ctx, cancel = context.WithTimeout(context.Background(), time.Nanosecond)
+			time.Sleep(time.Millisecond)
+		})
+
+		It("should return ErrTransport wrapping context.DeadlineExceeded", func() {
+			_, err = vault.V2GetContext(ctx, "secret", "foo", nil, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,252 @@
+package vaultkv
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Config contains the settings used by NewClient to construct a Client. Each
+// member corresponds to an environment variable understood by the vault CLI,
+// which ConfigFromEnv reads it from.
+type Config struct {
+	//Address is the URL of the Vault, which may be a unix:// URL. Unlike a Client
+	// built directly, an address without a port uses the default port of its
+	// scheme, as the vault CLI does. VAULT_ADDR
+	Address string
+	//Token is the auth token for the client to use. VAULT_TOKEN, or the
+	// contents of ~/.vault-token if VAULT_TOKEN is unset.
+	Token string
+	//Namespace is sent as the X-Vault-Namespace header. VAULT_NAMESPACE
+	Namespace string
+	//CACert is the path to a PEM-encoded CA certificate file used to verify
+	// the Vault's TLS certificate. VAULT_CACERT
+	CACert string
+	//CAPath is the path to a directory of PEM-encoded CA certificate files used
+	// to verify the Vault's TLS certificate. VAULT_CAPATH
+	CAPath string
+	//ClientCert is the path to a PEM-encoded client certificate for TLS
+	// authentication to the Vault. VAULT_CLIENT_CERT
+	ClientCert string
+	//ClientKey is the path to the private key for ClientCert. VAULT_CLIENT_KEY
+	ClientKey string
+	//SkipVerify disables verification of the Vault's TLS certificate.
+	// VAULT_SKIP_VERIFY
+	SkipVerify bool
+	//TLSServerName is the name to use as the SNI host when connecting to the
+	// Vault. VAULT_TLS_SERVER_NAME
+	TLSServerName string
+	//Timeout is the time limit for each request made to the Vault. Zero means
+	// no limit. VAULT_CLIENT_TIMEOUT
+	Timeout time.Duration
+	//MaxRetries is the number of times a failed idempotent request will be
+	// retried. Zero disables retries. VAULT_MAX_RETRIES
+	MaxRetries int
+	//RateLimit is the number of requests per second that the client may make,
+	// and RateBurst the number of requests it may make in a burst. Zero disables
+	// rate limiting. VAULT_RATE_LIMIT, given as "<rate>:<burst>" or "<rate>"
+	RateLimit float64
+	RateBurst int
+	//ProxyAddress is the URL of an HTTP proxy to send requests through. If
+	// empty, the proxy is taken from HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	// VAULT_PROXY_ADDR, or VAULT_HTTP_PROXY if that is unset
+	ProxyAddress string
+}
+
+const (
+	defaultEnvAddress = "https://127.0.0.1:8200"
+	defaultEnvTimeout = 60 * time.Second
+	defaultEnvRetries = 2
+)
+
+//ConfigFromEnv returns a Config populated from the VAULT_* environment
+// variables understood by the vault CLI. Unset variables take the same
+// defaults that the vault CLI uses: the address defaults to
+// https://127.0.0.1:8200, the timeout to 60 seconds, and the retry count to 2.
+// If VAULT_TOKEN is unset, the token is read from ~/.vault-token, if present.
+func ConfigFromEnv() (conf Config, err error) {
+	conf = Config{
+		Address:       os.Getenv("VAULT_ADDR"),
+		Token:         os.Getenv("VAULT_TOKEN"),
+		Namespace:     os.Getenv("VAULT_NAMESPACE"),
+		CACert:        os.Getenv("VAULT_CACERT"),
+		CAPath:        os.Getenv("VAULT_CAPATH"),
+		ClientCert:    os.Getenv("VAULT_CLIENT_CERT"),
+		ClientKey:     os.Getenv("VAULT_CLIENT_KEY"),
+		TLSServerName: os.Getenv("VAULT_TLS_SERVER_NAME"),
+		ProxyAddress:  os.Getenv("VAULT_PROXY_ADDR"),
+		Timeout:       defaultEnvTimeout,
+		MaxRetries:    defaultEnvRetries,
+	}
+
+	if conf.Address == "" {
+		conf.Address = defaultEnvAddress
+	}
+
+	if conf.ProxyAddress == "" {
+		conf.ProxyAddress = os.Getenv("VAULT_HTTP_PROXY")
+	}
+
+	if conf.Token == "" {
+		conf.Token, err = readTokenFile()
+		if err != nil {
+			return
+		}
+	}
+
+	if skip := os.Getenv("VAULT_SKIP_VERIFY"); skip != "" {
+		conf.SkipVerify, err = strconv.ParseBool(skip)
+		if err != nil {
+			err = fmt.Errorf("Could not parse VAULT_SKIP_VERIFY: %s", err)
+			return
+		}
+	}
+
+	if timeout := os.Getenv("VAULT_CLIENT_TIMEOUT"); timeout != "" {
+		conf.Timeout, err = parseEnvDuration(timeout)
+		if err != nil {
+			err = fmt.Errorf("Could not parse VAULT_CLIENT_TIMEOUT: %s", err)
+			return
+		}
+	}
+
+	if retries := os.Getenv("VAULT_MAX_RETRIES"); retries != "" {
+		conf.MaxRetries, err = strconv.Atoi(retries)
+		if err != nil {
+			err = fmt.Errorf("Could not parse VAULT_MAX_RETRIES: %s", err)
+			return
+		}
+	}
+
+	if limit := os.Getenv("VAULT_RATE_LIMIT"); limit != "" {
+		conf.RateLimit, conf.RateBurst, err = parseEnvRateLimit(limit)
+		if err != nil {
+			err = fmt.Errorf("Could not parse VAULT_RATE_LIMIT: %s", err)
+			return
+		}
+	}
+
+	return
+}
+
+//parseEnvRateLimit parses a rate limit of the form "<rate>:<burst>", as the
+// vault CLI does. If the burst is not given, it is the rate.
+func parseEnvRateLimit(s string) (rate float64, burst int, err error) {
+	parts := strings.SplitN(s, ":", 2)
+	rate, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return
+	}
+
+	burst = int(rate)
+	if len(parts) == 2 {
+		burst, err = strconv.Atoi(parts[1])
+	}
+
+	return
+}
+
+//parseEnvDuration accepts either a bare number of seconds, as the vault CLI
+// does, or a Go duration string such as "1m30s".
+func parseEnvDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.Atoi(s); err == nil {
+		return time.Duration(secs) * time.Second, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+//readTokenFile returns the contents of the ~/.vault-token file written by the
+// vault CLI's default token helper. No error is returned if it does not exist.
+func readTokenFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", nil
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(home, ".vault-token"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(string(contents)), nil
+}
+
+//NewClientFromEnv returns a Client configured from the VAULT_* environment
+// variables. See ConfigFromEnv for the variables read.
+func NewClientFromEnv() (*Client, error) {
+	conf, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClient(conf)
+}
+
+//NewClient returns a Client configured with the given Config. The returned
+// Client has its own HTTP client, and so does not share connections with
+// http.DefaultClient.
+func NewClient(conf Config) (*Client, error) {
+	if conf.Address == "" {
+		return nil, fmt.Errorf("no Vault address given")
+	}
+
+	vaultURL, err := url.Parse(conf.Address)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse Vault address `%s': %s", conf.Address, err)
+	}
+
+	tlsConfig := &TLSConfig{
+		CACert:             conf.CACert,
+		CAPath:             conf.CAPath,
+		ClientCert:         conf.ClientCert,
+		ClientKey:          conf.ClientKey,
+		ServerName:         conf.TLSServerName,
+		InsecureSkipVerify: conf.SkipVerify,
+	}
+
+	ret := &Client{
+		AuthToken:         conf.Token,
+		VaultURL:          vaultURL,
+		SchemeDefaultPort: true,
+		TLS:               tlsConfig,
+		Namespace:         conf.Namespace,
+	}
+
+	if conf.ProxyAddress != "" {
+		ret.Proxy, err = url.Parse(conf.ProxyAddress)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse proxy address `%s': %s", conf.ProxyAddress, err)
+		}
+	}
+
+	transport, err := ret.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	ret.Client = &http.Client{
+		Transport: transport,
+		Timeout:   conf.Timeout,
+	}
+
+	if conf.MaxRetries > 0 {
+		ret.RetryPolicy = DefaultRetryPolicy()
+		ret.RetryPolicy.MaxAttempts = conf.MaxRetries + 1
+	}
+
+	if conf.RateLimit > 0 {
+		ret.Limiter = NewLimiter(conf.RateLimit, conf.RateBurst, 0)
+	}
+
+	return ret, nil
+}
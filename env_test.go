@@ -0,0 +1,160 @@
+package vaultkv_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NewClientFromEnv", func() {
+	var envVars = []string{
+		"VAULT_ADDR", "VAULT_TOKEN", "VAULT_NAMESPACE", "VAULT_CACERT",
+		"VAULT_CAPATH", "VAULT_CLIENT_CERT", "VAULT_CLIENT_KEY",
+		"VAULT_SKIP_VERIFY", "VAULT_TLS_SERVER_NAME", "VAULT_CLIENT_TIMEOUT",
+		"VAULT_MAX_RETRIES", "VAULT_RATE_LIMIT", "HOME",
+	}
+	var savedEnv map[string]string
+	var homeDir string
+	var client *vaultkv.Client
+
+	BeforeEach(func() {
+		savedEnv = map[string]string{}
+		for _, name := range envVars {
+			savedEnv[name] = os.Getenv(name)
+			os.Unsetenv(name)
+		}
+
+		homeDir, err = ioutil.TempDir("", "vaultkv-test-home")
+		Expect(err).NotTo(HaveOccurred())
+		os.Setenv("HOME", homeDir)
+	})
+
+	AfterEach(func() {
+		for name, value := range savedEnv {
+			os.Setenv(name, value)
+		}
+		os.RemoveAll(homeDir)
+	})
+
+	JustBeforeEach(func() {
+		client, err = vaultkv.NewClientFromEnv()
+	})
+
+	When("no variables are set", func() {
+		It("should use the vault CLI defaults", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.VaultURL.String()).To(Equal("https://127.0.0.1:8200"))
+			Expect(client.AuthToken).To(BeEmpty())
+			Expect(client.Client.Timeout).To(Equal(60 * time.Second))
+			Expect(client.RetryPolicy).NotTo(BeNil())
+			Expect(client.RetryPolicy.MaxAttempts).To(Equal(3))
+			Expect(client.Limiter).To(BeNil())
+		})
+	})
+
+	When("the variables are set", func() {
+		BeforeEach(func() {
+			os.Setenv("VAULT_ADDR", "https://vault.example.com:8201")
+			os.Setenv("VAULT_TOKEN", "s.abcdef")
+			os.Setenv("VAULT_NAMESPACE", "team/")
+			os.Setenv("VAULT_CLIENT_TIMEOUT", "5")
+			os.Setenv("VAULT_MAX_RETRIES", "0")
+			os.Setenv("VAULT_RATE_LIMIT", "10.5:20")
+		})
+
+		It("should configure the client from them", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.VaultURL.String()).To(Equal("https://vault.example.com:8201"))
+			Expect(client.AuthToken).To(Equal("s.abcdef"))
+			Expect(client.Namespace).To(Equal("team/"))
+			Expect(client.Client.Timeout).To(Equal(5 * time.Second))
+			Expect(client.RetryPolicy).To(BeNil())
+			Expect(client.Limiter).NotTo(BeNil())
+		})
+	})
+
+	When("VAULT_TOKEN is unset and a token helper file exists", func() {
+		BeforeEach(func() {
+			err = ioutil.WriteFile(filepath.Join(homeDir, ".vault-token"), []byte("s.fromfile\n"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should read the token from the file", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.AuthToken).To(Equal("s.fromfile"))
+		})
+	})
+
+	When("VAULT_RATE_LIMIT is not a number", func() {
+		BeforeEach(func() {
+			os.Setenv("VAULT_RATE_LIMIT", "fast")
+		})
+
+		It("should err", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("VAULT_SKIP_VERIFY is not a boolean", func() {
+		BeforeEach(func() {
+			os.Setenv("VAULT_SKIP_VERIFY", "sure")
+		})
+
+		It("should err", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("VAULT_CLIENT_CERT is set without VAULT_CLIENT_KEY", func() {
+		BeforeEach(func() {
+			os.Setenv("VAULT_CLIENT_CERT", certLocation)
+		})
+
+		It("should err", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("VAULT_CACERT points to the Vault's CA certificate", func() {
+		BeforeEach(func() {
+			os.Setenv("VAULT_ADDR", vaultURI.String())
+			os.Setenv("VAULT_CACERT", certLocation)
+		})
+
+		It("should verify the Vault's certificate and connect", func() {
+			Expect(err).NotTo(HaveOccurred())
+			err = client.Health(true)
+			Expect(vaultkv.IsUninitialized(err)).To(BeTrue())
+		})
+	})
+
+	When("VAULT_CAPATH points to a directory with the Vault's CA certificate", func() {
+		var caDir string
+		BeforeEach(func() {
+			caDir, err = ioutil.TempDir("", "vaultkv-test-capath")
+			Expect(err).NotTo(HaveOccurred())
+			cert, err := ioutil.ReadFile(certLocation)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(caDir, "ca.pem"), cert, 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			os.Setenv("VAULT_ADDR", vaultURI.String())
+			os.Setenv("VAULT_CAPATH", caDir)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(caDir)
+		})
+
+		It("should verify the Vault's certificate and connect", func() {
+			Expect(err).NotTo(HaveOccurred())
+			err = client.Health(true)
+			Expect(vaultkv.IsUninitialized(err)).To(BeTrue())
+		})
+	})
+})
@@ -1,183 +1,412 @@
 package vaultkv
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
+//APIError describes an error response from the Vault API. The errors returned
+// for error responses, such as *ErrNotFound, wrap an *APIError, which can be
+// retrieved with errors.As. Error responses with a status code that has no
+// more specific error type are returned as a bare *APIError. So are error
+// responses whose body is not JSON, such as the error pages of proxies, in
+// which case Errors says why the body could not be parsed.
+//
+//The specific error types can be checked for with errors.Is by giving an empty
+// value of the type as the target, such as errors.Is(err, &ErrNotFound{}), or
+// with the IsX functions, such as IsNotFound. Both find the error even if it
+// has been wrapped with fmt.Errorf's %w verb.
+type APIError struct {
+	//StatusCode is the HTTP status code of the response
+	StatusCode int
+	//Method is the HTTP method of the request
+	Method string
+	//Path is the path of the request URL, such as /v1/secret/foo
+	Path string
+	//Errors is the list of error messages given by Vault
+	Errors []string
+	//RequestID is the ID that Vault assigned to the request, if Vault included
+	// it in the response. Vault does so for some errors, such as a 404 from
+	// reading a deleted KV v2 secret.
+	RequestID string
+}
+
+func newAPIError(r *http.Response, body apiError) *APIError {
+	ret := &APIError{
+		StatusCode: r.StatusCode,
+		Errors:     body.Errors,
+		RequestID:  body.RequestID,
+	}
+
+	if r.Request != nil {
+		ret.Method = r.Request.Method
+		ret.Path = r.Request.URL.Path
+	}
+
+	return ret
+}
+
+func (e *APIError) Error() string {
+	message := strings.Join(e.Errors, "\n")
+	if text := http.StatusText(e.StatusCode); text != "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, text, message)
+	}
+
+	return fmt.Sprintf("%d: %s", e.StatusCode, message)
+}
+
+//orNil returns e as an error, or a nil error if e is nil, so that a nil
+// *APIError does not become a non-nil error.
+func (e *APIError) orNil() error {
+	if e == nil {
+		return nil
+	}
+
+	return e
+}
+
 //ErrBadRequest represents 400 status codes that are returned from the API.
 //See: your fault.
 type ErrBadRequest struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrBadRequest) Error() string {
 	return fmt.Sprintf("400 Bad Request: %s", e.message)
 }
 
-//IsBadRequest returns true if the error is an ErrBadRequest
-func IsBadRequest(err error) bool {
-	_, is := err.(*ErrBadRequest)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrBadRequest) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrBadRequest, so that
+// errors.Is(err, &ErrBadRequest{}) can be used to check for this error type.
+func (e *ErrBadRequest) Is(target error) bool {
+	_, is := target.(*ErrBadRequest)
 	return is
 }
 
+//IsBadRequest returns true if the error is an ErrBadRequest, or wraps one
+func IsBadRequest(err error) bool {
+	return errors.As(err, new(*ErrBadRequest))
+}
+
 //ErrForbidden represents 403 status codes returned from the API. This could be
 // if your auth is wrong or expired, or you simply don't have access to do the
 // particular thing you're trying to do. Check your privilege.
 type ErrForbidden struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrForbidden) Error() string {
 	return fmt.Sprintf("403 Forbidden: %s", e.message)
 }
 
-//IsForbidden returns true if the error is an ErrForbidden
-func IsForbidden(err error) bool {
-	_, is := err.(*ErrForbidden)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrForbidden) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrForbidden, so that
+// errors.Is(err, &ErrForbidden{}) can be used to check for this error type.
+func (e *ErrForbidden) Is(target error) bool {
+	_, is := target.(*ErrForbidden)
 	return is
 }
 
+//IsForbidden returns true if the error is an ErrForbidden, or wraps one
+func IsForbidden(err error) bool {
+	return errors.As(err, new(*ErrForbidden))
+}
+
 //ErrNotFound represents 404 status codes returned from the API. This could be
 // either that the thing you're looking for doesn't exist, or in some cases
 // that you don't have access to the thing you're looking for and that Vault is
 // hiding it from you.
 type ErrNotFound struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrNotFound) Error() string {
 	return fmt.Sprintf("404 Not Found: %s", e.message)
 }
 
-//IsNotFound returns true if the error is an ErrNotFound
-func IsNotFound(err error) bool {
-	_, is := err.(*ErrNotFound)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrNotFound) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrNotFound, so that
+// errors.Is(err, &ErrNotFound{}) can be used to check for this error type.
+func (e *ErrNotFound) Is(target error) bool {
+	_, is := target.(*ErrNotFound)
 	return is
 }
 
+//IsNotFound returns true if the error is an ErrNotFound, or wraps one
+func IsNotFound(err error) bool {
+	return errors.As(err, new(*ErrNotFound))
+}
+
 //ErrStandby is only returned from Health() if standbyok is set to false and the
 // node you're querying is a standby.
 type ErrStandby struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrStandby) Error() string {
 	return fmt.Sprintf("429 Standby: %s", e.message)
 }
 
-//IsErrStandby returns true if the error is an ErrStandby
-func IsErrStandby(err error) bool {
-	_, is := err.(*ErrStandby)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrStandby) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrStandby, so that
+// errors.Is(err, &ErrStandby{}) can be used to check for this error type.
+func (e *ErrStandby) Is(target error) bool {
+	_, is := target.(*ErrStandby)
 	return is
 }
 
+//IsErrStandby returns true if the error is an ErrStandby, or wraps one
+func IsErrStandby(err error) bool {
+	return errors.As(err, new(*ErrStandby))
+}
+
 //ErrDRSecondary is only returned from Health() if standbyok is set to false
 //and the node you're querying is a secondary disaster recovery node.
 type ErrDRSecondary struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrDRSecondary) Error() string {
 	return fmt.Sprintf("472 DRSecondary: %s", e.message)
 }
 
-//IsErrDRSecondary returns true if the error is an ErrDRSecondary
-func IsErrDRSecondary(err error) bool {
-	_, is := err.(*ErrDRSecondary)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrDRSecondary) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrDRSecondary, so that
+// errors.Is(err, &ErrDRSecondary{}) can be used to check for this error type.
+func (e *ErrDRSecondary) Is(target error) bool {
+	_, is := target.(*ErrDRSecondary)
 	return is
 }
 
+//IsErrDRSecondary returns true if the error is an ErrDRSecondary, or wraps one
+func IsErrDRSecondary(err error) bool {
+	return errors.As(err, new(*ErrDRSecondary))
+}
+
 //ErrPerfStandby is only returned from Health() if standbyok is set to false
 //and the node you're querying is a performance standby node.
 type ErrPerfStandby struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrPerfStandby) Error() string {
 	return fmt.Sprintf("473 PerfStandby %s", e.message)
 }
 
-//IsErrPerfStandby returns true if the error is an ErrPerfStandby
-func IsErrPerfStandby(err error) bool {
-	_, is := err.(*ErrPerfStandby)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrPerfStandby) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrPerfStandby, so that
+// errors.Is(err, &ErrPerfStandby{}) can be used to check for this error type.
+func (e *ErrPerfStandby) Is(target error) bool {
+	_, is := target.(*ErrPerfStandby)
 	return is
 }
 
+//IsErrPerfStandby returns true if the error is an ErrPerfStandby, or wraps one
+func IsErrPerfStandby(err error) bool {
+	return errors.As(err, new(*ErrPerfStandby))
+}
+
 //IsAnyStandbyErr returns true if the error is that the node is a standby or a
 //performance standby
 func IsAnyStandbyErr(err error) bool {
 	return IsErrStandby(err) || IsErrPerfStandby(err)
 }
 
+//ErrRateLimited represents 429 status codes returned from the API when a
+// request exceeds a rate limit quota. Vault rejects such requests without
+// acting on them, so they are safe to retry once the quota allows. See Limiter
+// for keeping under a quota in the first place.
+type ErrRateLimited struct {
+	message string
+	api     *APIError
+	//RetryAfter is how long Vault asked the client to wait before retrying, or
+	// zero if it did not say.
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("429 Too Many Requests: %s", e.message)
+}
+
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrRateLimited) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrRateLimited, so that
+// errors.Is(err, &ErrRateLimited{}) can be used to check for this error type.
+func (e *ErrRateLimited) Is(target error) bool {
+	_, is := target.(*ErrRateLimited)
+	return is
+}
+
+//IsRateLimited returns true if the error is an ErrRateLimited, or wraps one
+func IsRateLimited(err error) bool {
+	return errors.As(err, new(*ErrRateLimited))
+}
+
 //ErrInternalServer represents 500 status codes that are returned from the API.
 //See: their fault.
 type ErrInternalServer struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrInternalServer) Error() string {
 	return fmt.Sprintf("500 Internal Server Error: %s", e.message)
 }
 
-//IsInternalServer returns true if the error is an ErrInternalServer
-func IsInternalServer(err error) bool {
-	_, is := err.(*ErrInternalServer)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrInternalServer) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrInternalServer, so that
+// errors.Is(err, &ErrInternalServer{}) can be used to check for this error type.
+func (e *ErrInternalServer) Is(target error) bool {
+	_, is := target.(*ErrInternalServer)
 	return is
 }
 
+//IsInternalServer returns true if the error is an ErrInternalServer, or wraps one
+func IsInternalServer(err error) bool {
+	return errors.As(err, new(*ErrInternalServer))
+}
+
 //ErrSealed represents the 503 status code that is returned by Vault most
 // commonly if the Vault is currently sealed, but could also represent the Vault
 // being in a maintenance state.
 type ErrSealed struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrSealed) Error() string {
 	return fmt.Sprintf("503 Sealed: %s", e.message)
 }
 
-//IsSealed returns true if the error is an ErrSealed
-func IsSealed(err error) bool {
-	_, is := err.(*ErrSealed)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrSealed) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrSealed, so that
+// errors.Is(err, &ErrSealed{}) can be used to check for this error type.
+func (e *ErrSealed) Is(target error) bool {
+	_, is := target.(*ErrSealed)
 	return is
 }
 
+//IsSealed returns true if the error is an ErrSealed, or wraps one
+func IsSealed(err error) bool {
+	return errors.As(err, new(*ErrSealed))
+}
+
 //ErrUninitialized represents a 503 status code being returned and the Vault
 //being uninitialized.
 type ErrUninitialized struct {
 	message string
+	api     *APIError
 }
 
 func (e *ErrUninitialized) Error() string {
 	return fmt.Sprintf("503 Uninitialized: %s", e.message)
 }
 
-//IsUninitialized returns true if the error is an ErrUninitialized
-func IsUninitialized(err error) bool {
-	_, is := err.(*ErrUninitialized)
+//Unwrap returns the *APIError describing the response that caused this error,
+// if there was one.
+func (e *ErrUninitialized) Unwrap() error {
+	return e.api.orNil()
+}
+
+//Is returns true if target is also an *ErrUninitialized, so that
+// errors.Is(err, &ErrUninitialized{}) can be used to check for this error type.
+func (e *ErrUninitialized) Is(target error) bool {
+	_, is := target.(*ErrUninitialized)
 	return is
 }
 
+//IsUninitialized returns true if the error is an ErrUninitialized, or wraps one
+func IsUninitialized(err error) bool {
+	return errors.As(err, new(*ErrUninitialized))
+}
+
 //ErrTransport is returned if an error was encountered trying to reach the API,
 // as opposed to an error from the API, is returned
 type ErrTransport struct {
 	message string
+	err     error
 }
 
 func (e *ErrTransport) Error() string {
 	return fmt.Sprintf("Transport Error: %s", e.message)
 }
 
-//IsTransport returns true if the error is an ErrTransport
-func IsTransport(err error) bool {
-	_, is := err.(*ErrTransport)
+//Unwrap returns the underlying error from the HTTP client, if any. This allows
+// errors.Is(err, context.Canceled) and errors.Is(err, context.DeadlineExceeded)
+// to detect requests ended by their context.
+func (e *ErrTransport) Unwrap() error {
+	return e.err
+}
+
+//Is returns true if target is also an *ErrTransport, so that
+// errors.Is(err, &ErrTransport{}) can be used to check for this error type.
+func (e *ErrTransport) Is(target error) bool {
+	_, is := target.(*ErrTransport)
 	return is
 }
 
+//IsTransport returns true if the error is an ErrTransport, or wraps one
+func IsTransport(err error) bool {
+	return errors.As(err, new(*ErrTransport))
+}
+
 //ErrKVUnsupported is returned by the KV object when the user requests an
 // operation that cannot be performed by the actual version of the KV backend
 // that the KV object is abstracting
@@ -189,62 +418,99 @@ func (e *ErrKVUnsupported) Error() string {
 	return fmt.Sprintf("Operation unsupported by KV version: %s", e.message)
 }
 
-//IsErrKVUnsupported returns true if the error is an ErrKVUnsupported
-func IsErrKVUnsupported(err error) bool {
-	_, is := err.(*ErrKVUnsupported)
+//Is returns true if target is also an *ErrKVUnsupported, so that
+// errors.Is(err, &ErrKVUnsupported{}) can be used to check for this error type.
+func (e *ErrKVUnsupported) Is(target error) bool {
+	_, is := target.(*ErrKVUnsupported)
 	return is
 }
 
+//IsErrKVUnsupported returns true if the error is an ErrKVUnsupported, or wraps one
+func IsErrKVUnsupported(err error) bool {
+	return errors.As(err, new(*ErrKVUnsupported))
+}
+
 type apiError struct {
-	Errors []string `json:"errors"`
+	Errors    []string `json:"errors"`
+	RequestID string   `json:"request_id"`
 }
 
-func (v *Client) parseError(r *http.Response) (err error) {
+func (v *Client) parseError(ctx context.Context, r *http.Response) (err error) {
+	//The body is closed before a 503 probes the health of the Vault, as the
+	// probe would otherwise wait on the in-flight slot of this response when
+	// the Client has a Limiter
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return err
+	}
+
 	errorsStruct := apiError{}
-	err = json.NewDecoder(r.Body).Decode(&errorsStruct)
+	err = json.Unmarshal(body, &errorsStruct)
 	if err != nil {
+		//Error pages from proxies and load balancers are still returned as API
+		// errors, with why they could not be parsed as their message
+		message := fmt.Sprintf("Could not parse response body as JSON: %s", err)
 		if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
-			return fmt.Errorf("Could not parse response body as JSON, and returned Content-Type is `%s'. Client may not be reaching Vault", contentType)
+			message = fmt.Sprintf("Could not parse response body as JSON, and returned Content-Type is `%s'. Client may not be reaching Vault", contentType)
 		}
-		return err
+		errorsStruct = apiError{Errors: []string{message}}
+	}
+	api := newAPIError(r, errorsStruct)
+
+	if r.StatusCode == 503 {
+		return v.parse503(ctx, api)
 	}
-	errorMessage := strings.Join(errorsStruct.Errors, "\n")
 
-	switch r.StatusCode {
+	if err = api.specific(r); err == nil {
+		err = api
+	}
+
+	return
+}
+
+//specific returns the error type specific to the status code of the API error,
+// wrapping the API error, or nil if there is no such type.
+func (e *APIError) specific(r *http.Response) error {
+	message := strings.Join(e.Errors, "\n")
+	switch e.StatusCode {
 	case 400:
-		err = &ErrBadRequest{message: errorMessage}
+		return &ErrBadRequest{message: message, api: e}
 	case 403:
-		err = &ErrForbidden{message: errorMessage}
+		return &ErrForbidden{message: message, api: e}
 	case 404:
-		err = &ErrNotFound{message: errorMessage}
+		return &ErrNotFound{message: message, api: e}
+	case 429:
+		ret := &ErrRateLimited{message: message, api: e}
+		if secs, err := strconv.Atoi(r.Header.Get("Retry-After")); err == nil {
+			ret.RetryAfter = time.Duration(secs) * time.Second
+		}
+		return ret
 	case 500:
-		err = &ErrInternalServer{message: errorMessage}
-	case 503:
-		err = v.parse503(errorMessage)
-	default:
-		err = errors.New(errorMessage)
+		return &ErrInternalServer{message: message, api: e}
 	}
 
-	return
+	return nil
 }
 
-func (v *Client) parse503(message string) (err error) {
-	err = v.Health(true)
-	if err == nil {
-		return nil
-	}
+//parse503 returns the error for the state of the Vault that the health probe
+// finds, or the API error itself if the probe does not explain the 503, such
+// as when it came from a proxy in front of the Vault.
+func (v *Client) parse503(ctx context.Context, api *APIError) (err error) {
+	err = v.HealthContext(ctx, true)
 
+	message := strings.Join(api.Errors, "\n")
 	switch e := err.(type) {
 	case *ErrStandby:
-		e.message = message
-		err = e
+		e.message, e.api = message, api
+		return e
 	case *ErrUninitialized:
-		e.message = message
-		err = e
+		e.message, e.api = message, api
+		return e
 	case *ErrSealed:
-		e.message = message
-		err = e
+		e.message, e.api = message, api
+		return e
 	}
 
-	return err
+	return api
 }
@@ -0,0 +1,184 @@
+package vaultkv_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Errors", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var status int
+	var body string
+	var contentType string
+
+	BeforeEach(func() {
+		contentType = "application/json"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("the API returns a 404", func() {
+		BeforeEach(func() {
+			status = http.StatusNotFound
+			body = `{"request_id": "abcd-1234", "errors": ["no secret here"]}`
+		})
+
+		JustBeforeEach(func() {
+			err = client.Get("secret/foo", nil)
+		})
+
+		It("should be an ErrNotFound", func() {
+			Expect(vaultkv.IsNotFound(err)).To(BeTrue())
+			Expect(errors.Is(err, &vaultkv.ErrNotFound{})).To(BeTrue())
+			Expect(errors.Is(err, &vaultkv.ErrForbidden{})).To(BeFalse())
+		})
+
+		It("should carry the details of the request", func() {
+			var apiErr *vaultkv.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(apiErr.Method).To(Equal("GET"))
+			Expect(apiErr.Path).To(Equal("/v1/secret/foo"))
+			Expect(apiErr.Errors).To(Equal([]string{"no secret here"}))
+			Expect(apiErr.RequestID).To(Equal("abcd-1234"))
+		})
+
+		When("the error has been wrapped", func() {
+			JustBeforeEach(func() {
+				err = fmt.Errorf("could not get secret: %w", err)
+			})
+
+			It("should still be detected", func() {
+				Expect(vaultkv.IsNotFound(err)).To(BeTrue())
+				Expect(errors.Is(err, &vaultkv.ErrNotFound{})).To(BeTrue())
+
+				var notFound *vaultkv.ErrNotFound
+				Expect(errors.As(err, &notFound)).To(BeTrue())
+
+				var apiErr *vaultkv.APIError
+				Expect(errors.As(err, &apiErr)).To(BeTrue())
+				Expect(apiErr.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	When("the API returns a status with no specific error type", func() {
+		BeforeEach(func() {
+			status = http.StatusPreconditionFailed
+			body = `{"errors": ["required index state not present"]}`
+		})
+
+		JustBeforeEach(func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+		})
+
+		It("should return an APIError", func() {
+			var apiErr *vaultkv.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusPreconditionFailed))
+			Expect(apiErr.Method).To(Equal("PUT"))
+			Expect(err.Error()).To(Equal("412 Precondition Failed: required index state not present"))
+		})
+	})
+
+	When("the API returns a 503 because the Vault is sealed", func() {
+		BeforeEach(func() {
+			status = http.StatusServiceUnavailable
+			body = `{"errors": ["Vault is sealed"]}`
+		})
+
+		JustBeforeEach(func() {
+			err = client.Get("secret/foo", nil)
+		})
+
+		It("should be an ErrSealed describing the original request", func() {
+			Expect(errors.Is(err, &vaultkv.ErrSealed{})).To(BeTrue())
+
+			var apiErr *vaultkv.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.Path).To(Equal("/v1/secret/foo"))
+		})
+	})
+
+	When("a proxy returns an error page", func() {
+		BeforeEach(func() {
+			status = http.StatusBadGateway
+			contentType = "text/html"
+			body = `<html><body><h1>502 Bad Gateway</h1></body></html>`
+		})
+
+		JustBeforeEach(func() {
+			err = client.Get("secret/foo", nil)
+		})
+
+		It("should return an APIError saying why the body could not be parsed", func() {
+			var apiErr *vaultkv.APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(apiErr.Method).To(Equal("GET"))
+			Expect(apiErr.Path).To(Equal("/v1/secret/foo"))
+			Expect(err.Error()).To(ContainSubstring("Content-Type is `text/html'"))
+		})
+
+		When("the status has a specific error type", func() {
+			BeforeEach(func() {
+				status = http.StatusTooManyRequests
+				contentType = "text/plain"
+				body = "Too Many Requests"
+			})
+
+			It("should return that type", func() {
+				Expect(vaultkv.IsRateLimited(err)).To(BeTrue())
+			})
+		})
+
+		When("the status is a 503 which the health of the Vault does not explain", func() {
+			BeforeEach(func() {
+				status = http.StatusServiceUnavailable
+			})
+
+			It("should return an APIError rather than an ErrSealed", func() {
+				Expect(errors.Is(err, &vaultkv.ErrSealed{})).To(BeFalse())
+
+				var apiErr *vaultkv.APIError
+				Expect(errors.As(err, &apiErr)).To(BeTrue())
+				Expect(apiErr.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+		})
+	})
+
+	When("the Vault cannot be reached", func() {
+		JustBeforeEach(func() {
+			server.Close()
+			err = client.Get("secret/foo", nil)
+		})
+
+		It("should be an ErrTransport without an APIError", func() {
+			Expect(errors.Is(fmt.Errorf("wrapped: %w", err), &vaultkv.ErrTransport{})).To(BeTrue())
+			Expect(errors.As(err, new(*vaultkv.APIError))).To(BeFalse())
+		})
+	})
+})
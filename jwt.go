@@ -0,0 +1,90 @@
+package vaultkv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+//AuthJWTMetadata is the metadata member set by AuthJWT.
+type AuthJWTMetadata struct {
+	//Role is the role that was logged in as
+	Role string
+	//ClaimMappings holds the values of the claims of the JWT which the role maps
+	// to metadata, keyed by the metadata names that they are mapped to.
+	ClaimMappings map[string]string
+}
+
+//UnmarshalJSON decodes the flat metadata map that Vault returns, in which the
+// claim mappings sit alongside the role.
+func (m *AuthJWTMetadata) UnmarshalJSON(b []byte) error {
+	raw := map[string]string{}
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+
+	m.Role = raw["role"]
+	delete(raw, "role")
+	m.ClaimMappings = raw
+	return nil
+}
+
+//AuthJWT is a shorthand for AuthJWTMount against the default jwt mountpoint,
+// 'jwt'.
+func (v *Client) AuthJWT(role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthJWTMount("jwt", role, jwt)
+}
+
+//AuthJWTContext is AuthJWT with a context governing the request.
+func (v *Client) AuthJWTContext(ctx context.Context, role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthJWTMountContext(ctx, "jwt", role, jwt)
+}
+
+//AuthJWTMount submits the given JWT to the jwt auth endpoint at the given
+// mount, to log in as the given role. This is the non-interactive login of the
+// jwt and oidc auth methods, for JWTs issued to machines, such as the OIDC
+// tokens of CI providers, or SPIFFE JWT-SVIDs. If role is empty, the default
+// role of the mount is used. If the JWT is accepted, then the AuthOutput object
+// is returned, with Metadata of type AuthJWTMetadata, and this client's
+// AuthToken is set to the returned token. Given mountpoint is relative to
+// /v1/auth.
+func (v *Client) AuthJWTMount(mount, role, jwt string) (ret *AuthOutput, err error) {
+	return v.AuthJWTMountContext(context.Background(), mount, role, jwt)
+}
+
+//AuthJWTMountContext is AuthJWTMount with a context governing the request.
+func (v *Client) AuthJWTMountContext(ctx context.Context, mount, role, jwt string) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	err = v.doRequest(
+		ctx,
+		"POST",
+		fmt.Sprintf("/auth/%s/login", mount),
+		struct {
+			Role string `json:"role,omitempty"`
+			JWT  string `json:"jwt"`
+		}{
+			Role: role,
+			JWT:  jwt,
+		},
+		&raw,
+	)
+	if err != nil {
+		return
+	}
+
+	ret, err = raw.toFinal(AuthJWTMetadata{})
+	if err != nil {
+		return
+	}
+	v.SetAuthToken(ret.ClientToken)
+
+	return
+}
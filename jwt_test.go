@@ -0,0 +1,83 @@
+package vaultkv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthJWT", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var login map[string]string
+	var output *vaultkv.AuthOutput
+	var role string
+
+	BeforeEach(func() {
+		login = nil
+		role = "ci"
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			Expect(r.URL.Path).To(Equal("/v1/auth/gitlab/login"))
+			Expect(json.NewDecoder(r.Body).Decode(&login)).To(Succeed())
+
+			if login["jwt"] != "eyJ.ci.jwt" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":["error validating token: invalid signature"]}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"auth":{"client_token":"s.jwt","policies":["ci"],"lease_duration":1200,"metadata":{"role":"ci","project_path":"infra/deploy","ref":"main"}}}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		output, err = client.AuthJWTMount("gitlab", role, "eyJ.ci.jwt")
+	})
+
+	It("should send the role and the JWT", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(login).To(Equal(map[string]string{"role": "ci", "jwt": "eyJ.ci.jwt"}))
+	})
+
+	It("should set the token and return the claim mappings", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.AuthToken).To(Equal("s.jwt"))
+		Expect(output.Policies).To(Equal([]string{"ci"}))
+		Expect(output.Metadata).To(Equal(vaultkv.AuthJWTMetadata{
+			Role: "ci",
+			ClaimMappings: map[string]string{
+				"project_path": "infra/deploy",
+				"ref":          "main",
+			},
+		}))
+	})
+
+	When("no role is given", func() {
+		BeforeEach(func() {
+			role = ""
+		})
+
+		It("should leave the role to the default of the mount", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(login).NotTo(HaveKey("role"))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -22,14 +23,14 @@ type KV struct {
 }
 
 type kvMount interface {
-	Get(mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error)
-	Set(mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error)
-	List(mount, subpath string) (paths []string, err error)
-	Delete(mount, subpath string, opts *KVDeleteOpts) (err error)
-	Undelete(mount, subpath string, versions []uint) (err error)
-	Destroy(mount, subpath string, versions []uint) (err error)
-	DestroyAll(mount, subpath string) (err error)
-	Versions(mount, subpath string) (ret []KVVersion, err error)
+	Get(ctx context.Context, mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error)
+	Set(ctx context.Context, mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error)
+	List(ctx context.Context, mount, subpath string) (paths []string, err error)
+	Delete(ctx context.Context, mount, subpath string, opts *KVDeleteOpts) (err error)
+	Undelete(ctx context.Context, mount, subpath string, versions []uint) (err error)
+	Destroy(ctx context.Context, mount, subpath string, versions []uint) (err error)
+	DestroyAll(ctx context.Context, mount, subpath string) (err error)
+	Versions(ctx context.Context, mount, subpath string) (ret []KVVersion, err error)
 	MountVersion() (version uint)
 }
 
@@ -46,48 +47,57 @@ func v1ConstructPath(mount, subpath string) string {
 	return strings.Trim(fmt.Sprintf("%s/%s", mount, subpath), "/")
 }
 
-func (k kvv1Mount) Get(mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
+func (k kvv1Mount) Get(ctx context.Context, mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
 	if opts != nil && opts.Version > 1 {
-		err = &ErrNotFound{"No versions greater than one in KV v1 backend"}
+		err = &ErrNotFound{message: "No versions greater than one in KV v1 backend"}
 		return
 	}
 
 	path := v1ConstructPath(mount, subpath)
-	err = k.client.Get(path, output)
+	if opts != nil && opts.Response != nil {
+		var resp *Response
+		resp, err = k.client.DoContext(ctx, "GET", path, nil, output)
+		if err == nil {
+			*opts.Response = *resp
+		}
+	} else {
+		err = k.client.GetContext(ctx, path, output)
+	}
+
 	if err == nil {
 		meta.Version = 1
 	}
 	return
 }
 
-func (k kvv1Mount) List(mount, subpath string) (paths []string, err error) {
+func (k kvv1Mount) List(ctx context.Context, mount, subpath string) (paths []string, err error) {
 	path := v1ConstructPath(mount, subpath)
-	return k.client.List(path)
+	return k.client.ListContext(ctx, path)
 }
 
-func (k kvv1Mount) Set(mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
+func (k kvv1Mount) Set(ctx context.Context, mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
 	path := v1ConstructPath(mount, subpath)
-	err = k.client.Set(path, values)
+	err = k.client.SetContext(ctx, path, values)
 	if err == nil {
 		meta.Version = 1
 	}
 	return
 }
 
-func (k kvv1Mount) Delete(mount, subpath string, opts *KVDeleteOpts) (err error) {
+func (k kvv1Mount) Delete(ctx context.Context, mount, subpath string, opts *KVDeleteOpts) (err error) {
 	if opts == nil || !opts.V1Destroy {
-		return &ErrKVUnsupported{"Refusing to destroy KV v1 value from delete call"}
+		return &ErrKVUnsupported{message: "Refusing to destroy KV v1 value from delete call"}
 	}
 
 	//opts should be non-nil here because of the check earlier in the function
-	return k.Destroy(mount, subpath, opts.Versions)
+	return k.Destroy(ctx, mount, subpath, opts.Versions)
 }
 
-func (k kvv1Mount) Undelete(mount, subpath string, versions []uint) (err error) {
-	return &ErrKVUnsupported{"Cannot undelete secret in KV v1 backend"}
+func (k kvv1Mount) Undelete(ctx context.Context, mount, subpath string, versions []uint) (err error) {
+	return &ErrKVUnsupported{message: "Cannot undelete secret in KV v1 backend"}
 }
 
-func (k kvv1Mount) Destroy(mount, subpath string, versions []uint) (err error) {
+func (k kvv1Mount) Destroy(ctx context.Context, mount, subpath string, versions []uint) (err error) {
 	shouldDelete := len(versions) == 0
 	for _, v := range versions {
 		if v <= 1 {
@@ -97,19 +107,19 @@ func (k kvv1Mount) Destroy(mount, subpath string, versions []uint) (err error) {
 
 	if shouldDelete {
 		path := v1ConstructPath(mount, subpath)
-		err = k.client.Delete(path)
+		err = k.client.DeleteContext(ctx, path)
 	}
 	return err
 }
 
-func (k kvv1Mount) DestroyAll(mount, subpath string) (err error) {
+func (k kvv1Mount) DestroyAll(ctx context.Context, mount, subpath string) (err error) {
 	path := v1ConstructPath(mount, subpath)
-	return k.client.Delete(path)
+	return k.client.DeleteContext(ctx, path)
 }
 
-func (k kvv1Mount) Versions(mount, subpath string) (ret []KVVersion, err error) {
+func (k kvv1Mount) Versions(ctx context.Context, mount, subpath string) (ret []KVVersion, err error) {
 	path := v1ConstructPath(mount, subpath)
-	err = k.client.Get(path, nil)
+	err = k.client.GetContext(ctx, path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -128,16 +138,17 @@ type kvv2Mount struct {
 	client *Client
 }
 
-func (k kvv2Mount) Get(mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
+func (k kvv2Mount) Get(ctx context.Context, mount, subpath string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
 	var o *V2GetOpts
 	if opts != nil {
 		o = &V2GetOpts{
-			Version: opts.Version,
+			Version:  opts.Version,
+			Response: opts.Response,
 		}
 	}
 
 	var m V2Version
-	m, err = k.client.V2Get(mount, subpath, output, o)
+	m, err = k.client.V2GetContext(ctx, mount, subpath, output, o)
 	if err == nil {
 		meta.Deleted = m.DeletedAt != nil
 		meta.Destroyed = m.Destroyed
@@ -147,13 +158,13 @@ func (k kvv2Mount) Get(mount, subpath string, output interface{}, opts *KVGetOpt
 	return
 }
 
-func (k kvv2Mount) List(mount, subpath string) (paths []string, err error) {
-	return k.client.V2List(mount, subpath)
+func (k kvv2Mount) List(ctx context.Context, mount, subpath string) (paths []string, err error) {
+	return k.client.V2ListContext(ctx, mount, subpath)
 }
 
-func (k kvv2Mount) Set(mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
+func (k kvv2Mount) Set(ctx context.Context, mount, subpath string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
 	var m V2Version
-	m, err = k.client.V2Set(mount, subpath, values, nil)
+	m, err = k.client.V2SetContext(ctx, mount, subpath, values, nil)
 	if err == nil {
 		meta.Version = m.Version
 		meta.CreatedAt = m.CreatedAt
@@ -161,29 +172,29 @@ func (k kvv2Mount) Set(mount, subpath string, values interface{}, opts *KVSetOpt
 	return
 }
 
-func (k kvv2Mount) Delete(mount, subpath string, opts *KVDeleteOpts) (err error) {
+func (k kvv2Mount) Delete(ctx context.Context, mount, subpath string, opts *KVDeleteOpts) (err error) {
 	versions := []uint{}
 	if opts != nil {
 		versions = opts.Versions
 	}
-	return k.client.V2Delete(mount, subpath, &V2DeleteOpts{Versions: versions})
+	return k.client.V2DeleteContext(ctx, mount, subpath, &V2DeleteOpts{Versions: versions})
 }
 
-func (k kvv2Mount) Undelete(mount, subpath string, versions []uint) (err error) {
-	return k.client.V2Undelete(mount, subpath, versions)
+func (k kvv2Mount) Undelete(ctx context.Context, mount, subpath string, versions []uint) (err error) {
+	return k.client.V2UndeleteContext(ctx, mount, subpath, versions)
 }
 
-func (k kvv2Mount) Destroy(mount, subpath string, versions []uint) (err error) {
-	return k.client.V2Destroy(mount, subpath, versions)
+func (k kvv2Mount) Destroy(ctx context.Context, mount, subpath string, versions []uint) (err error) {
+	return k.client.V2DestroyContext(ctx, mount, subpath, versions)
 }
 
-func (k kvv2Mount) DestroyAll(mount, subpath string) (err error) {
-	return k.client.V2DestroyMetadata(mount, subpath)
+func (k kvv2Mount) DestroyAll(ctx context.Context, mount, subpath string) (err error) {
+	return k.client.V2DestroyMetadataContext(ctx, mount, subpath)
 }
 
-func (k kvv2Mount) Versions(mount, subpath string) (ret []KVVersion, err error) {
+func (k kvv2Mount) Versions(ctx context.Context, mount, subpath string) (ret []KVVersion, err error) {
 	var meta V2Metadata
-	meta, err = k.client.V2GetMetadata(mount, subpath)
+	meta, err = k.client.V2GetMetadataContext(ctx, mount, subpath)
 	if err != nil {
 		return nil, err
 	}
@@ -211,7 +222,9 @@ func (v *Client) NewKV() *KV {
 	return &KV{Client: v, mounts: map[string]kvMount{}}
 }
 
-func (k *KV) mountForPath(path string) (mountPath string, ret kvMount, err error) {
+func (k *KV) mountForPath(ctx context.Context, path string) (mountPath string, ret kvMount, err error) {
+	//The mount lookup is not the request that the caller asked to be wrapped
+	ctx = withoutWrapping(ctx)
 	pathParts := strings.Split(strings.Trim(path, "/"), "/")
 	var found bool
 	k.lock.RLock()
@@ -240,7 +253,7 @@ func (k *KV) mountForPath(path string) (mountPath string, ret kvMount, err error
 		return
 	}
 
-	mountPath, isV2, err := k.Client.IsKVv2Mount(path)
+	mountPath, isV2, err := k.Client.IsKVv2MountContext(ctx, path)
 	if err != nil {
 		return
 	}
@@ -270,6 +283,9 @@ type KVGetOpts struct {
 	// Version is the version of the resource to retrieve. Setting this to zero (or
 	// not setting it at all) will retrieve the latest version
 	Version uint
+	// Response, if non-nil, is populated with the envelope of the response,
+	// such as its request ID and any warnings
+	Response *Response
 }
 
 //KVVersion contains information about a version of a secret.
@@ -290,26 +306,36 @@ func (k KVVersion) Alive() bool {
 //semantics of Client.Get or Client.V2Get, chosen based on the backend mounted
 //at the path given.
 func (k *KV) Get(path string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.GetContext(context.Background(), path, output, opts)
+}
+
+//GetContext is Get with a context governing the requests.
+func (k *KV) GetContext(ctx context.Context, path string, output interface{}, opts *KVGetOpts) (meta KVVersion, err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Get(mountPath, path, output, opts)
+	return mount.Get(ctx, mountPath, path, output, opts)
 }
 
 //List retrieves the paths under the given path. If the path does not exist or
 //it is not a folder, ErrNotFound is thrown. Results ending with a slash are
 //folders.
 func (k *KV) List(path string) (paths []string, err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.ListContext(context.Background(), path)
+}
+
+//ListContext is List with a context governing the requests.
+func (k *KV) ListContext(ctx context.Context, path string) (paths []string, err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.List(mountPath, path)
+	return mount.List(ctx, mountPath, path)
 }
 
 //KVSetOpts are the options for a set call to the KV.Set() call. Currently there
@@ -320,13 +346,18 @@ type KVSetOpts struct{}
 //Set puts the values given at the path given. If KV v1, the previous value, if
 //any, is overwritten.  If KV v2, a new version is created.
 func (k *KV) Set(path string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.SetContext(context.Background(), path, values, opts)
+}
+
+//SetContext is Set with a context governing the requests.
+func (k *KV) SetContext(ctx context.Context, path string, values interface{}, opts *KVSetOpts) (meta KVVersion, err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Set(mountPath, path, values, opts)
+	return mount.Set(ctx, mountPath, path, values, opts)
 }
 
 //KVDeleteOpts are options applicable to KV.Delete
@@ -345,71 +376,101 @@ type KVDeleteOpts struct {
 // For KV v1, temporarily deleting a secret is not possible. Use the V1Destroy
 // option as a way to safeguard against unwanted destruction of secrets.
 func (k *KV) Delete(path string, opts *KVDeleteOpts) (err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.DeleteContext(context.Background(), path, opts)
+}
+
+//DeleteContext is Delete with a context governing the requests.
+func (k *KV) DeleteContext(ctx context.Context, path string, opts *KVDeleteOpts) (err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Delete(mountPath, path, opts)
+	return mount.Delete(ctx, mountPath, path, opts)
 }
 
 //Undelete attempts to unmark deletion on a previously deleted version.
 // KV v1 backends cannot do this, and so if the backend is KV v1, this
 // returns an ErrKVUnsupported.
 func (k *KV) Undelete(path string, versions []uint) (err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.UndeleteContext(context.Background(), path, versions)
+}
+
+//UndeleteContext is Undelete with a context governing the requests.
+func (k *KV) UndeleteContext(ctx context.Context, path string, versions []uint) (err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Undelete(mountPath, path, versions)
+	return mount.Undelete(ctx, mountPath, path, versions)
 }
 
 //Destroy attempts to irrevocably delete the given versions at the given
 // path. For KV v1 backends, this is a call to Client.Delete. for KV v2
 // backends, this is a call to Client.V2Destroy
 func (k *KV) Destroy(path string, versions []uint) (err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.DestroyContext(context.Background(), path, versions)
+}
+
+//DestroyContext is Destroy with a context governing the requests.
+func (k *KV) DestroyContext(ctx context.Context, path string, versions []uint) (err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Destroy(mountPath, path, versions)
+	return mount.Destroy(ctx, mountPath, path, versions)
 }
 
 //DestroyAll attempts to irrevocably delete all versions of the secret
 // at the given path. For KV v1 backends, this is a call to Client.Delete.
 // For v2 backends, this is a call to Client.V2DestroyMetadata
 func (k *KV) DestroyAll(path string) (err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.DestroyAllContext(context.Background(), path)
+}
+
+//DestroyAllContext is DestroyAll with a context governing the requests.
+func (k *KV) DestroyAllContext(ctx context.Context, path string) (err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.DestroyAll(mountPath, path)
+	return mount.DestroyAll(ctx, mountPath, path)
 }
 
 //Versions returns the versions of the secret available. If no secret
 // exists at this path, ErrNotFound is returned. If the secret exists
 // and this is a KV v1 backend, one version is returned.
 func (k *KV) Versions(path string) (ret []KVVersion, err error) {
-	mountPath, mount, err := k.mountForPath(path)
+	return k.VersionsContext(context.Background(), path)
+}
+
+//VersionsContext is Versions with a context governing the requests.
+func (k *KV) VersionsContext(ctx context.Context, path string) (ret []KVVersion, err error) {
+	mountPath, mount, err := k.mountForPath(ctx, path)
 	if err != nil {
 		return
 	}
 
 	path = subtractMount(mountPath, path)
-	return mount.Versions(mountPath, path)
+	return mount.Versions(ctx, mountPath, path)
 }
 
 //MountVersion returns the KV version of the mount for the given path.
 // v1 mounts return 1; v2 mounts return 2.
 func (k *KV) MountVersion(mount string) (version uint, err error) {
-	_, m, err := k.mountForPath(mount)
+	return k.MountVersionContext(context.Background(), mount)
+}
+
+//MountVersionContext is MountVersion with a context governing the request.
+func (k *KV) MountVersionContext(ctx context.Context, mount string) (version uint, err error) {
+	_, m, err := k.mountForPath(ctx, mount)
 	if err != nil {
 		return
 	}
@@ -420,6 +481,11 @@ func (k *KV) MountVersion(mount string) (version uint, err error) {
 //MountPath returns the path of the mount on which the given path is mounted.
 // If no such mount can be found, an error is returned.
 func (k *KV) MountPath(path string) (mount string, err error) {
-	mount, _, err = k.mountForPath(path)
+	return k.MountPathContext(context.Background(), path)
+}
+
+//MountPathContext is MountPath with a context governing the request.
+func (k *KV) MountPathContext(ctx context.Context, path string) (mount string, err error) {
+	mount, _, err = k.mountForPath(ctx, path)
 	return
 }
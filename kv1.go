@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -15,12 +16,17 @@ import (
 //endpoint to work. No assumptions are made about the mounting point of your
 //Key/Value backend.
 func (v *Client) Get(path string, output interface{}) error {
+	return v.GetContext(context.Background(), path, output)
+}
+
+//GetContext is Get with a context governing the request.
+func (v *Client) GetContext(ctx context.Context, path string, output interface{}) error {
 	if output != nil &&
 		reflect.ValueOf(output).Kind() != reflect.Ptr {
 		return fmt.Errorf("Get output target must be a pointer if non-nil")
 	}
 
-	err := v.doRequest("GET", path, nil, &vaultResponse{Data: output})
+	err := v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: output})
 	if err != nil {
 		return err
 	}
@@ -35,11 +41,16 @@ func (v *Client) Get(path string, output interface{}) error {
 //work. No assumptions are made about the mounting point of your Key/Value
 //backend.
 func (v *Client) List(path string) ([]string, error) {
+	return v.ListContext(context.Background(), path)
+}
+
+//ListContext is List with a context governing the request.
+func (v *Client) ListContext(ctx context.Context, path string) ([]string, error) {
 	ret := []string{}
 
 	query := url.Values{}
 	query.Add("list", "true")
-	err := v.doRequest("GET", path, query, &vaultResponse{
+	err := v.doRequest(ctx, "GET", path, query, &vaultResponse{
 		Data: &struct {
 			Keys *[]string `json:"keys"`
 		}{
@@ -58,11 +69,21 @@ func (v *Client) List(path string) ([]string, error) {
 //struct). The Vault must be unsealed and initialized for this endpoint to work.
 //No assumptions are made about the mounting point of your Key/Value backend.
 func (v *Client) Set(path string, values interface{}) error {
-	return v.doRequest("PUT", path, &values, nil)
+	return v.SetContext(context.Background(), path, values)
+}
+
+//SetContext is Set with a context governing the request.
+func (v *Client) SetContext(ctx context.Context, path string, values interface{}) error {
+	return v.doRequest(ctx, "PUT", path, &values, nil)
 }
 
 //Delete attempts to delete the value at the specified path. No error is
 //returned if there is already no value at the given path.
 func (v *Client) Delete(path string) error {
-	return v.doRequest("DELETE", path, nil, nil)
+	return v.DeleteContext(context.Background(), path)
+}
+
+//DeleteContext is Delete with a context governing the request.
+func (v *Client) DeleteContext(ctx context.Context, path string) error {
+	return v.doRequest(ctx, "DELETE", path, nil, nil)
 }
@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -28,6 +29,11 @@ func mountPathDefault(path string) string {
 //mount point or if the Vault is too old to have the API endpoint to look for
 //the mount. If a different API error occurs, it will be propagated out.
 func (c *Client) IsKVv2Mount(path string) (mountPath string, isV2 bool, err error) {
+	return c.IsKVv2MountContext(context.Background(), path)
+}
+
+//IsKVv2MountContext is IsKVv2Mount with a context governing the requests.
+func (c *Client) IsKVv2MountContext(ctx context.Context, path string) (mountPath string, isV2 bool, err error) {
 	path = strings.TrimPrefix(path, "/")
 	output := struct {
 		Data struct {
@@ -41,6 +47,7 @@ func (c *Client) IsKVv2Mount(path string) (mountPath string, isV2 bool, err erro
 	}{}
 
 	err = c.doRequest(
+		ctx,
 		"GET",
 		fmt.Sprintf("/sys/internal/ui/mounts"),
 		nil, &output)
@@ -57,7 +64,7 @@ func (c *Client) IsKVv2Mount(path string) (mountPath string, isV2 bool, err erro
 		// interpreting this as a call to the sys/* region which this token may not have
 		// access to. In this case, it would be too old of a version to have a v2 backend.
 		if _, is403 := err.(*ErrForbidden); is403 {
-			if c.TokenIsValid() == nil {
+			if c.TokenIsValidContext(withoutWrapping(ctx)) == nil {
 				err = nil
 			}
 		}
@@ -125,6 +132,9 @@ type V2GetOpts struct {
 	// Version is the version of the resource to retrieve. Setting this to zero (or
 	// not setting it at all) will retrieve the latest version
 	Version uint
+	// Response, if non-nil, is populated with the envelope of the response,
+	// such as its request ID and any warnings
+	Response *Response
 }
 
 //V2Get will get a secret from the given path in a KV version 2 secrets backend.
@@ -133,6 +143,11 @@ type V2GetOpts struct {
 //by output using encoding/json.Unmarshal semantics. The version to retrieve
 //can be selected by setting Version in the V2GetOpts struct at opts.
 func (c *Client) V2Get(mount, subpath string, output interface{}, opts *V2GetOpts) (meta V2Version, err error) {
+	return c.V2GetContext(context.Background(), mount, subpath, output, opts)
+}
+
+//V2GetContext is V2Get with a context governing the request.
+func (c *Client) V2GetContext(ctx context.Context, mount, subpath string, output interface{}, opts *V2GetOpts) (meta V2Version, err error) {
 	if output != nil &&
 		reflect.ValueOf(output).Kind() != reflect.Ptr {
 		err = fmt.Errorf("V2Get output target must be a pointer if non-nil")
@@ -144,14 +159,11 @@ func (c *Client) V2Get(mount, subpath string, output interface{}, opts *V2GetOpt
 		Data     interface{}  `json:"data"`
 	}
 
-	unmarshalInto := &struct {
-		Data outputData `json:"data"`
-	}{
-		Data: outputData{
-			Metadata: v2VersionAPI{},
-			Data:     output,
-		},
+	data := &outputData{
+		Metadata: v2VersionAPI{},
+		Data:     output,
 	}
+	unmarshalInto := &responseAPI{Data: data}
 
 	query := url.Values{}
 	if opts != nil {
@@ -159,12 +171,16 @@ func (c *Client) V2Get(mount, subpath string, output interface{}, opts *V2GetOpt
 	}
 
 	path := fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
-	err = c.doRequest("GET", path, query, unmarshalInto)
+	err = c.doRequest(ctx, "GET", path, query, unmarshalInto)
 	if err != nil {
 		return
 	}
 
-	meta = unmarshalInto.Data.Metadata.Parse()
+	if opts != nil && opts.Response != nil {
+		*opts.Response = *unmarshalInto.Parse()
+	}
+
+	meta = data.Metadata.Parse()
 	return
 }
 
@@ -175,12 +191,17 @@ func (c *Client) V2Get(mount, subpath string, output interface{}, opts *V2GetOpt
 //work. No assumptions are made about the mounting point of your Key/Value
 //backend.
 func (c *Client) V2List(mount, subpath string) ([]string, error) {
+	return c.V2ListContext(context.Background(), mount, subpath)
+}
+
+//V2ListContext is V2List with a context governing the request.
+func (c *Client) V2ListContext(ctx context.Context, mount, subpath string) ([]string, error) {
 	ret := []string{}
 	path := fmt.Sprintf("%s/metadata/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
 
 	query := url.Values{}
 	query.Add("list", "true")
-	err := c.doRequest("GET", path, query, &vaultResponse{
+	err := c.doRequest(ctx, "GET", path, query, &vaultResponse{
 		Data: &struct {
 			Keys *[]string `json:"keys"`
 		}{
@@ -221,6 +242,11 @@ func (s V2SetOpts) WithCAS(i uint) *V2SetOpts {
 // check-and-set functionality. Returns the metadata about the written secret
 // if the write is successful.
 func (c *Client) V2Set(mount, subpath string, values interface{}, opts *V2SetOpts) (meta V2Version, err error) {
+	return c.V2SetContext(context.Background(), mount, subpath, values, opts)
+}
+
+//V2SetContext is V2Set with a context governing the request.
+func (c *Client) V2SetContext(ctx context.Context, mount, subpath string, values interface{}, opts *V2SetOpts) (meta V2Version, err error) {
 	input := struct {
 		Options *V2SetOpts  `json:"options,omitempty"`
 		Data    interface{} `json:"data"`
@@ -237,7 +263,7 @@ func (c *Client) V2Set(mount, subpath string, values interface{}, opts *V2SetOpt
 
 	path := fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
 
-	err = c.doRequest("PUT", path, &input, &output)
+	err = c.doRequest(ctx, "PUT", path, &input, &output)
 	if err != nil {
 		return
 	}
@@ -256,6 +282,11 @@ type V2DeleteOpts struct {
 // deleted. Otherwise, the specified versions are deleted. Note that the deleted
 // data from this call is recoverable from a call to V2Undelete.
 func (c *Client) V2Delete(mount, subpath string, opts *V2DeleteOpts) error {
+	return c.V2DeleteContext(context.Background(), mount, subpath, opts)
+}
+
+//V2DeleteContext is V2Delete with a context governing the request.
+func (c *Client) V2DeleteContext(ctx context.Context, mount, subpath string, opts *V2DeleteOpts) error {
 	method := "DELETE"
 	path := fmt.Sprintf("%s/data/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
 
@@ -266,13 +297,18 @@ func (c *Client) V2Delete(mount, subpath string, opts *V2DeleteOpts) error {
 		opts = nil
 	}
 
-	return c.doRequest(method, path, opts, nil)
+	return c.doRequest(ctx, method, path, opts, nil)
 }
 
 //V2Undelete marks the specified versions at the specified paths as not deleted.
 func (c *Client) V2Undelete(mount, subpath string, versions []uint) error {
+	return c.V2UndeleteContext(context.Background(), mount, subpath, versions)
+}
+
+//V2UndeleteContext is V2Undelete with a context governing the request.
+func (c *Client) V2UndeleteContext(ctx context.Context, mount, subpath string, versions []uint) error {
 	path := fmt.Sprintf("%s/undelete/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
-	return c.doRequest("POST", path, struct {
+	return c.doRequest(ctx, "POST", path, struct {
 		Versions []uint `json:"versions"`
 	}{
 		Versions: versions,
@@ -281,8 +317,13 @@ func (c *Client) V2Undelete(mount, subpath string, versions []uint) error {
 
 //V2Destroy permanently deletes the specified versions at the specified path.
 func (c *Client) V2Destroy(mount, subpath string, versions []uint) error {
+	return c.V2DestroyContext(context.Background(), mount, subpath, versions)
+}
+
+//V2DestroyContext is V2Destroy with a context governing the request.
+func (c *Client) V2DestroyContext(ctx context.Context, mount, subpath string, versions []uint) error {
 	path := fmt.Sprintf("%s/destroy/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
-	return c.doRequest("POST", path, struct {
+	return c.doRequest(ctx, "POST", path, struct {
 		Versions []uint `json:"versions"`
 	}{
 		Versions: versions,
@@ -292,8 +333,14 @@ func (c *Client) V2Destroy(mount, subpath string, versions []uint) error {
 //V2DestroyMetadata permanently destroys all secret versions and all metadata
 // associated with the secret at the specified path.
 func (c *Client) V2DestroyMetadata(mount, subpath string) error {
+	return c.V2DestroyMetadataContext(context.Background(), mount, subpath)
+}
+
+//V2DestroyMetadataContext is V2DestroyMetadata with a context governing the
+//request.
+func (c *Client) V2DestroyMetadataContext(ctx context.Context, mount, subpath string) error {
 	path := fmt.Sprintf("%s/metadata/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
-	return c.doRequest("DELETE", path, nil, nil)
+	return c.doRequest(ctx, "DELETE", path, nil, nil)
 }
 
 //V2Metadata is the metadata associated with a secret
@@ -367,9 +414,14 @@ func (m v2MetadataAPI) Parse() V2Metadata {
 //V2GetMetadata gets the metadata associated with the secret at the specified
 // path.
 func (c *Client) V2GetMetadata(mount, subpath string) (meta V2Metadata, err error) {
+	return c.V2GetMetadataContext(context.Background(), mount, subpath)
+}
+
+//V2GetMetadataContext is V2GetMetadata with a context governing the request.
+func (c *Client) V2GetMetadataContext(ctx context.Context, mount, subpath string) (meta V2Metadata, err error) {
 	path := fmt.Sprintf("%s/metadata/%s", strings.Trim(mount, "/"), strings.Trim(subpath, "/"))
 	output := v2MetadataAPI{}
-	err = c.doRequest("GET", path, nil, &output)
+	err = c.doRequest(ctx, "GET", path, nil, &output)
 	if err != nil {
 		return
 	}
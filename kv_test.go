@@ -116,6 +116,28 @@ var _ = Describe("KV", func() {
 						By("returning the same values that were set")
 						Expect(testGetOutput).To(Equal(testSetValues))
 					})
+
+					When("a Response is given in the options", func() {
+						var testGetResponse vaultkv.Response
+						JustBeforeEach(func() {
+							testGetResponse = vaultkv.Response{}
+							testGetOutput = map[string]string{}
+							testGetVersionOutput, err = testkv.Get(testSetPath, &testGetOutput, &vaultkv.KVGetOpts{
+								Response: &testGetResponse,
+							})
+						})
+
+						It("should populate the Response", func() {
+							By("not erroring")
+							Expect(err).NotTo(HaveOccurred())
+
+							By("returning the same values that were set")
+							Expect(testGetOutput).To(Equal(testSetValues))
+
+							By("having a request ID in the Response")
+							Expect(testGetResponse.RequestID).NotTo(BeEmpty())
+						})
+					})
 				})
 
 				Describe("Delete", func() {
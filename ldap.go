@@ -0,0 +1,378 @@
+package vaultkv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//LDAPConfig is the configuration of an LDAP auth method, which says how to
+// find and bind as users in the directory, and how to find their groups. Only
+// the members which are set are sent by LDAPConfigWrite, so the booleans are
+// pointers, which are nil when not set.
+type LDAPConfig struct {
+	//URL is the LDAP server to connect to, such as ldaps://ldap.example.com.
+	// Several may be given, separated by commas, to be tried in order.
+	URL string
+	//UserDN is the base DN under which to search for users.
+	UserDN string
+	//UserAttr is the attribute of users matched against the username given
+	// when logging in, such as "uid" or "sAMAccountName".
+	UserAttr   string
+	UserFilter string
+	//UPNDomain makes users bind as username@UPNDomain, for Active Directory.
+	UPNDomain string
+	//DiscoverDN searches for the DN of users to bind as, using BindDN and
+	// BindPass if given, instead of building it from UserAttr and UserDN.
+	DiscoverDN *bool
+	//GroupDN is the base DN under which to search for the groups of users.
+	GroupDN string
+	//GroupFilter is the Go template of the search filter for the groups of a
+	// user.
+	GroupFilter string
+	//GroupAttr is the attribute of groups that holds their names, as mapped to
+	// policies with LDAPGroupWrite.
+	GroupAttr      string
+	UseTokenGroups *bool
+	//BindDN is the DN to bind as when searching the directory.
+	BindDN string
+	//BindPass is the password of BindDN. It is not returned by LDAPConfigRead,
+	// so a config read with it can be written back without changing it.
+	BindPass string
+	//DenyNullBind stops users from logging in with an empty password, which
+	// some directories treat as an anonymous bind that always succeeds. Vault
+	// denies them by default.
+	DenyNullBind *bool
+	//Certificate is the PEM encoded CA certificate with which to verify the
+	// LDAP server.
+	Certificate        string
+	InsecureTLS        *bool
+	StartTLS           *bool
+	TLSMinVersion      string
+	TLSMaxVersion      string
+	CaseSensitiveNames *bool
+	//TokenPolicies are given to the tokens of all users, in addition to those
+	// of their groups and of the users themselves.
+	TokenPolicies []string
+	TokenTTL      time.Duration
+	TokenMaxTTL   time.Duration
+}
+
+type ldapConfigAPI struct {
+	URL                string   `json:"url,omitempty"`
+	UserDN             string   `json:"userdn,omitempty"`
+	UserAttr           string   `json:"userattr,omitempty"`
+	UserFilter         string   `json:"userfilter,omitempty"`
+	UPNDomain          string   `json:"upndomain,omitempty"`
+	DiscoverDN         *bool    `json:"discoverdn,omitempty"`
+	GroupDN            string   `json:"groupdn,omitempty"`
+	GroupFilter        string   `json:"groupfilter,omitempty"`
+	GroupAttr          string   `json:"groupattr,omitempty"`
+	UseTokenGroups     *bool    `json:"use_token_groups,omitempty"`
+	BindDN             string   `json:"binddn,omitempty"`
+	BindPass           string   `json:"bindpass,omitempty"`
+	DenyNullBind       *bool    `json:"deny_null_bind,omitempty"`
+	Certificate        string   `json:"certificate,omitempty"`
+	InsecureTLS        *bool    `json:"insecure_tls,omitempty"`
+	StartTLS           *bool    `json:"starttls,omitempty"`
+	TLSMinVersion      string   `json:"tls_min_version,omitempty"`
+	TLSMaxVersion      string   `json:"tls_max_version,omitempty"`
+	CaseSensitiveNames *bool    `json:"case_sensitive_names,omitempty"`
+	TokenPolicies      []string `json:"token_policies,omitempty"`
+	TokenTTL           int64    `json:"token_ttl,omitempty"`
+	TokenMaxTTL        int64    `json:"token_max_ttl,omitempty"`
+}
+
+func (c LDAPConfig) toAPI() ldapConfigAPI {
+	return ldapConfigAPI{
+		URL:                c.URL,
+		UserDN:             c.UserDN,
+		UserAttr:           c.UserAttr,
+		UserFilter:         c.UserFilter,
+		UPNDomain:          c.UPNDomain,
+		DiscoverDN:         c.DiscoverDN,
+		GroupDN:            c.GroupDN,
+		GroupFilter:        c.GroupFilter,
+		GroupAttr:          c.GroupAttr,
+		UseTokenGroups:     c.UseTokenGroups,
+		BindDN:             c.BindDN,
+		BindPass:           c.BindPass,
+		DenyNullBind:       c.DenyNullBind,
+		Certificate:        c.Certificate,
+		InsecureTLS:        c.InsecureTLS,
+		StartTLS:           c.StartTLS,
+		TLSMinVersion:      c.TLSMinVersion,
+		TLSMaxVersion:      c.TLSMaxVersion,
+		CaseSensitiveNames: c.CaseSensitiveNames,
+		TokenPolicies:      c.TokenPolicies,
+		TokenTTL:           int64(c.TokenTTL / time.Second),
+		TokenMaxTTL:        int64(c.TokenMaxTTL / time.Second),
+	}
+}
+
+func (c ldapConfigAPI) Parse() *LDAPConfig {
+	return &LDAPConfig{
+		URL:                c.URL,
+		UserDN:             c.UserDN,
+		UserAttr:           c.UserAttr,
+		UserFilter:         c.UserFilter,
+		UPNDomain:          c.UPNDomain,
+		DiscoverDN:         c.DiscoverDN,
+		GroupDN:            c.GroupDN,
+		GroupFilter:        c.GroupFilter,
+		GroupAttr:          c.GroupAttr,
+		UseTokenGroups:     c.UseTokenGroups,
+		BindDN:             c.BindDN,
+		DenyNullBind:       c.DenyNullBind,
+		Certificate:        c.Certificate,
+		InsecureTLS:        c.InsecureTLS,
+		StartTLS:           c.StartTLS,
+		TLSMinVersion:      c.TLSMinVersion,
+		TLSMaxVersion:      c.TLSMaxVersion,
+		CaseSensitiveNames: c.CaseSensitiveNames,
+		TokenPolicies:      c.TokenPolicies,
+		TokenTTL:           time.Duration(c.TokenTTL) * time.Second,
+		TokenMaxTTL:        time.Duration(c.TokenMaxTTL) * time.Second,
+	}
+}
+
+//ldapPath returns the path of the LDAP auth method at the given mount, followed
+// by the given elements.
+func ldapPath(mount string, elems ...string) (string, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return "", fmt.Errorf("no mountpoint given")
+	}
+
+	return fmt.Sprintf("/auth/%s/%s", mount, strings.Join(elems, "/")), nil
+}
+
+//ldapEntityPath returns the path of the group or user with the given name in
+// the LDAP auth method at the given mount.
+func ldapEntityPath(mount, kind, name string) (string, error) {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return "", fmt.Errorf("no name given")
+	}
+
+	return ldapPath(mount, kind, name)
+}
+
+//LDAPConfigWrite updates the configuration of the LDAP auth method at the
+// given mount. Members which are empty or nil are not sent, so that they are
+// left as they are, or take Vault's defaults if the mount has not yet been
+// configured. As a result, TokenPolicies and the TTLs cannot be cleared with
+// it. Given mountpoint is relative to /v1/auth.
+func (v *Client) LDAPConfigWrite(mount string, config LDAPConfig) error {
+	return v.LDAPConfigWriteContext(context.Background(), mount, config)
+}
+
+//LDAPConfigWriteContext is LDAPConfigWrite with a context governing the
+// request.
+func (v *Client) LDAPConfigWriteContext(ctx context.Context, mount string, config LDAPConfig) error {
+	path, err := ldapPath(mount, "config")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, config.toAPI(), nil)
+}
+
+//LDAPConfigRead returns the configuration of the LDAP auth method at the given
+// mount, without its BindPass.
+func (v *Client) LDAPConfigRead(mount string) (*LDAPConfig, error) {
+	return v.LDAPConfigReadContext(context.Background(), mount)
+}
+
+//LDAPConfigReadContext is LDAPConfigRead with a context governing the request.
+func (v *Client) LDAPConfigReadContext(ctx context.Context, mount string) (*LDAPConfig, error) {
+	path, err := ldapPath(mount, "config")
+	if err != nil {
+		return nil, err
+	}
+
+	raw := ldapConfigAPI{}
+	err = v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Parse(), nil
+}
+
+//LDAPGroupWrite maps the LDAP group with the given name to the given policies
+// in the LDAP auth method at the given mount, such that they are given to the
+// tokens of users in the group. The name is matched against the GroupAttr of
+// the groups of a user.
+func (v *Client) LDAPGroupWrite(mount, name string, policies []string) error {
+	return v.LDAPGroupWriteContext(context.Background(), mount, name, policies)
+}
+
+//LDAPGroupWriteContext is LDAPGroupWrite with a context governing the request.
+func (v *Client) LDAPGroupWriteContext(ctx context.Context, mount, name string, policies []string) error {
+	path, err := ldapEntityPath(mount, "groups", name)
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		Policies []string `json:"policies"`
+	}{
+		Policies: policies,
+	}, nil)
+}
+
+//LDAPGroupRead returns the policies that the LDAP group with the given name is
+// mapped to in the LDAP auth method at the given mount. If the group is not
+// mapped, an *ErrNotFound is returned.
+func (v *Client) LDAPGroupRead(mount, name string) ([]string, error) {
+	return v.LDAPGroupReadContext(context.Background(), mount, name)
+}
+
+//LDAPGroupReadContext is LDAPGroupRead with a context governing the request.
+func (v *Client) LDAPGroupReadContext(ctx context.Context, mount, name string) ([]string, error) {
+	path, err := ldapEntityPath(mount, "groups", name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := struct {
+		Policies []string `json:"policies"`
+	}{}
+	err = v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Policies, nil
+}
+
+//LDAPGroupList returns the names of all of the LDAP groups that are mapped to
+// policies in the LDAP auth method at the given mount.
+func (v *Client) LDAPGroupList(mount string) ([]string, error) {
+	return v.LDAPGroupListContext(context.Background(), mount)
+}
+
+//LDAPGroupListContext is LDAPGroupList with a context governing the request.
+func (v *Client) LDAPGroupListContext(ctx context.Context, mount string) ([]string, error) {
+	path, err := ldapPath(mount, "groups")
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ListContext(ctx, path)
+}
+
+//LDAPGroupDelete removes the mapping of the LDAP group with the given name in
+// the LDAP auth method at the given mount.
+func (v *Client) LDAPGroupDelete(mount, name string) error {
+	return v.LDAPGroupDeleteContext(context.Background(), mount, name)
+}
+
+//LDAPGroupDeleteContext is LDAPGroupDelete with a context governing the
+// request.
+func (v *Client) LDAPGroupDeleteContext(ctx context.Context, mount, name string) error {
+	path, err := ldapEntityPath(mount, "groups", name)
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "DELETE", path, nil, nil)
+}
+
+//LDAPUser is the mapping of an LDAP user to policies and groups, which apply
+// in addition to those of the groups that the user is in within the directory.
+type LDAPUser struct {
+	Policies []string
+	//Groups are the names of LDAP groups, as mapped with LDAPGroupWrite, which
+	// the user is treated as being in.
+	Groups []string
+}
+
+//LDAPUserWrite maps the LDAP user with the given username in the LDAP auth
+// method at the given mount.
+func (v *Client) LDAPUserWrite(mount, username string, user LDAPUser) error {
+	return v.LDAPUserWriteContext(context.Background(), mount, username, user)
+}
+
+//LDAPUserWriteContext is LDAPUserWrite with a context governing the request.
+func (v *Client) LDAPUserWriteContext(ctx context.Context, mount, username string, user LDAPUser) error {
+	path, err := ldapEntityPath(mount, "users", username)
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		Policies []string `json:"policies"`
+		Groups   []string `json:"groups"`
+	}{
+		Policies: user.Policies,
+		Groups:   user.Groups,
+	}, nil)
+}
+
+//LDAPUserRead returns the mapping of the LDAP user with the given username in
+// the LDAP auth method at the given mount. If the user is not mapped, an
+// *ErrNotFound is returned.
+func (v *Client) LDAPUserRead(mount, username string) (*LDAPUser, error) {
+	return v.LDAPUserReadContext(context.Background(), mount, username)
+}
+
+//LDAPUserReadContext is LDAPUserRead with a context governing the request.
+func (v *Client) LDAPUserReadContext(ctx context.Context, mount, username string) (*LDAPUser, error) {
+	path, err := ldapEntityPath(mount, "users", username)
+	if err != nil {
+		return nil, err
+	}
+
+	//Vault returns the groups as a comma separated string
+	raw := struct {
+		Policies []string `json:"policies"`
+		Groups   string   `json:"groups"`
+	}{}
+	err = v.doRequest(ctx, "GET", path, nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &LDAPUser{Policies: raw.Policies}
+	if raw.Groups != "" {
+		ret.Groups = strings.Split(raw.Groups, ",")
+	}
+
+	return ret, nil
+}
+
+//LDAPUserList returns the usernames of all of the LDAP users that are mapped in
+// the LDAP auth method at the given mount.
+func (v *Client) LDAPUserList(mount string) ([]string, error) {
+	return v.LDAPUserListContext(context.Background(), mount)
+}
+
+//LDAPUserListContext is LDAPUserList with a context governing the request.
+func (v *Client) LDAPUserListContext(ctx context.Context, mount string) ([]string, error) {
+	path, err := ldapPath(mount, "users")
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ListContext(ctx, path)
+}
+
+//LDAPUserDelete removes the mapping of the LDAP user with the given username in
+// the LDAP auth method at the given mount.
+func (v *Client) LDAPUserDelete(mount, username string) error {
+	return v.LDAPUserDeleteContext(context.Background(), mount, username)
+}
+
+//LDAPUserDeleteContext is LDAPUserDelete with a context governing the request.
+func (v *Client) LDAPUserDeleteContext(ctx context.Context, mount, username string) error {
+	path, err := ldapEntityPath(mount, "users", username)
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "DELETE", path, nil, nil)
+}
+
@@ -0,0 +1,162 @@
+package vaultkv_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LDAP", func() {
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		err = vault.EnableAuthMount("ldap", vaultkv.Mount{Type: "ldap"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("LDAPConfigWrite", func() {
+		JustBeforeEach(func() {
+			err = vault.LDAPConfigWrite("ldap", vaultkv.LDAPConfig{
+				URL:           "ldaps://ldap.example.com",
+				UserDN:        "ou=Users,dc=example,dc=com",
+				UserAttr:      "uid",
+				GroupDN:       "ou=Groups,dc=example,dc=com",
+				BindDN:        "cn=vault,dc=example,dc=com",
+				BindPass:      "secret",
+				TokenPolicies: []string{"default"},
+				TokenTTL:      time.Hour,
+			})
+		})
+
+		It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+		Describe("LDAPConfigRead", func() {
+			var config *vaultkv.LDAPConfig
+			JustBeforeEach(func() {
+				config, err = vault.LDAPConfigRead("ldap")
+			})
+
+			It("should return the config without the bind password", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.URL).To(Equal("ldaps://ldap.example.com"))
+				Expect(config.UserDN).To(Equal("ou=Users,dc=example,dc=com"))
+				Expect(config.UserAttr).To(Equal("uid"))
+				Expect(config.GroupDN).To(Equal("ou=Groups,dc=example,dc=com"))
+				Expect(config.BindDN).To(Equal("cn=vault,dc=example,dc=com"))
+				Expect(config.BindPass).To(BeEmpty())
+				Expect(config.DenyNullBind).NotTo(BeNil())
+				Expect(*config.DenyNullBind).To(BeTrue())
+				Expect(config.TokenPolicies).To(Equal([]string{"default"}))
+				Expect(config.TokenTTL).To(Equal(time.Hour))
+			})
+		})
+
+		Describe("LDAPConfigWrite with only some members set", func() {
+			var config *vaultkv.LDAPConfig
+			JustBeforeEach(func() {
+				err = vault.LDAPConfigWrite("ldap", vaultkv.LDAPConfig{
+					URL: "ldaps://ldap2.example.com",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				config, err = vault.LDAPConfigRead("ldap")
+			})
+
+			It("should leave the other members as they were", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.URL).To(Equal("ldaps://ldap2.example.com"))
+				Expect(config.UserDN).To(Equal("ou=Users,dc=example,dc=com"))
+				Expect(config.TokenPolicies).To(Equal([]string{"default"}))
+				Expect(config.TokenTTL).To(Equal(time.Hour))
+			})
+		})
+	})
+
+	Describe("LDAPGroupWrite", func() {
+		JustBeforeEach(func() {
+			err = vault.LDAPGroupWrite("ldap", "admins", []string{"admin", "default"})
+		})
+
+		It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+		Describe("LDAPGroupRead", func() {
+			var policies []string
+			JustBeforeEach(func() {
+				policies, err = vault.LDAPGroupRead("ldap", "admins")
+			})
+
+			It("should return the policies of the group", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(policies).To(ConsistOf("admin", "default"))
+			})
+		})
+
+		Describe("LDAPGroupList", func() {
+			var groups []string
+			JustBeforeEach(func() {
+				groups, err = vault.LDAPGroupList("ldap")
+			})
+
+			It("should list the group", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(groups).To(Equal([]string{"admins"}))
+			})
+		})
+
+		Describe("LDAPGroupDelete", func() {
+			JustBeforeEach(func() {
+				err = vault.LDAPGroupDelete("ldap", "admins")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.LDAPGroupRead("ldap", "admins")
+			})
+
+			It("should remove the mapping", AssertErrorOfType(&vaultkv.ErrNotFound{}))
+		})
+	})
+
+	Describe("LDAPUserWrite", func() {
+		JustBeforeEach(func() {
+			err = vault.LDAPUserWrite("ldap", "alice", vaultkv.LDAPUser{
+				Policies: []string{"audit"},
+				Groups:   []string{"admins", "ops"},
+			})
+		})
+
+		It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+		Describe("LDAPUserRead", func() {
+			var user *vaultkv.LDAPUser
+			JustBeforeEach(func() {
+				user, err = vault.LDAPUserRead("ldap", "alice")
+			})
+
+			It("should return the policies and groups of the user", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(user.Policies).To(Equal([]string{"audit"}))
+				Expect(user.Groups).To(ConsistOf("admins", "ops"))
+			})
+		})
+
+		Describe("LDAPUserList", func() {
+			var users []string
+			JustBeforeEach(func() {
+				users, err = vault.LDAPUserList("ldap")
+			})
+
+			It("should list the user", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(users).To(Equal([]string{"alice"}))
+			})
+		})
+
+		Describe("LDAPUserDelete", func() {
+			JustBeforeEach(func() {
+				err = vault.LDAPUserDelete("ldap", "alice")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.LDAPUserRead("ldap", "alice")
+			})
+
+			It("should remove the mapping", AssertErrorOfType(&vaultkv.ErrNotFound{}))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+package vaultkv
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//Limiter caps the rate and the concurrency of the requests made by the Clients
+// which share it, so that bulk operations do not exhaust the rate limit quotas
+// of the Vault or degrade it for other users. Set it as the Limiter member of a
+// Client to use it. A request counts as in flight from when it is sent until
+// the body of its response is closed.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	lock     sync.Mutex
+	tokens   float64
+	last     time.Time
+	inFlight chan struct{}
+}
+
+//NewLimiter returns a Limiter which allows requests at an average of rate per
+// second, in bursts of up to burst requests, with no more than maxInFlight
+// requests in flight at once. A rate of zero or less places no limit on the
+// rate, and a maxInFlight of zero or less places no limit on concurrency. A
+// burst of less than one is taken to be one.
+func NewLimiter(rate float64, burst int, maxInFlight int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	ret := &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+	}
+
+	if maxInFlight > 0 {
+		ret.inFlight = make(chan struct{}, maxInFlight)
+	}
+
+	return ret
+}
+
+//acquire blocks until a request may be sent, and returns a function which must
+// be called once the request is no longer in flight. If the context ends
+// first, an ErrTransport wrapping the context's error is returned.
+func (l *Limiter) acquire(ctx context.Context) (release func(), err error) {
+	release = func() {}
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+			release = func() { <-l.inFlight }
+		case <-ctx.Done():
+			return nil, &ErrTransport{message: ctx.Err().Error(), err: ctx.Err()}
+		}
+	}
+
+	for l.rate > 0 {
+		wait := l.take()
+		if wait == 0 {
+			break
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, &ErrTransport{message: ctx.Err().Error(), err: ctx.Err()}
+		}
+	}
+
+	return release, nil
+}
+
+//take takes a token from the bucket if one is available and returns zero.
+// Otherwise, it returns how long it will be until one is.
+func (l *Limiter) take() time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
+
+//limitMiddleware holds each request until the Limiter of the Client allows
+// it, and keeps it counted as in flight until its response body is closed.
+func (v *Client) limitMiddleware(info RequestInfo, req *http.Request, next RoundTripFunc) (*http.Response, error) {
+	if v.Limiter == nil {
+		return next(req)
+	}
+
+	release, err := v.Limiter.acquire(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := next(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+//releasingBody calls release the first time that it is closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
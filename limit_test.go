@@ -0,0 +1,201 @@
+package vaultkv_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Limiter", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var inFlight, maxInFlight int32
+
+	BeforeEach(func() {
+		inFlight, maxInFlight = 0, 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			current := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if current <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, current) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	getConcurrently := func(n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				Expect(client.Get("secret/foo", nil)).To(Succeed())
+			}()
+		}
+		wg.Wait()
+	}
+
+	When("the number of requests in flight is capped", func() {
+		BeforeEach(func() {
+			client.Limiter = vaultkv.NewLimiter(0, 0, 2)
+		})
+
+		It("should not exceed the cap", func() {
+			getConcurrently(10)
+			Expect(atomic.LoadInt32(&maxInFlight)).To(BeEquivalentTo(2))
+		})
+
+		It("should count a request as in flight until its body is closed", func() {
+			resp, err := client.Curl("GET", "secret/foo", nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			resp2, err := client.Curl("GET", "secret/foo", nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			_, err = client.CurlContext(ctx, "GET", "secret/foo", nil, nil)
+			Expect(errors.Is(err, context.DeadlineExceeded)).To(BeTrue())
+
+			_, _ = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			Expect(client.Get("secret/foo", nil)).To(Succeed())
+			resp2.Body.Close()
+		})
+	})
+
+	When("the rate is limited", func() {
+		BeforeEach(func() {
+			client.Limiter = vaultkv.NewLimiter(50, 2, 0)
+		})
+
+		It("should allow a burst, and then requests at the rate", func() {
+			start := time.Now()
+			getConcurrently(2)
+			Expect(time.Since(start)).To(BeNumerically("<", 50*time.Millisecond))
+
+			start = time.Now()
+			getConcurrently(5)
+			//Five more requests at 50 per second take around 100ms
+			Expect(time.Since(start)).To(BeNumerically(">=", 80*time.Millisecond))
+		})
+	})
+})
+
+var _ = Describe("Limiter with a sealed Vault", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"errors":["Vault is sealed"]}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+			Limiter:  vaultkv.NewLimiter(0, 0, 1),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should probe the health of the Vault without waiting on the failed request", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = client.GetContext(ctx, "secret/foo", nil)
+		Expect(vaultkv.IsSealed(err)).To(BeTrue())
+		Expect(ctx.Err()).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Rate limit quota responses", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var requests int32
+
+	BeforeEach(func() {
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if atomic.AddInt32(&requests, 1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"errors":["request path \"secret/foo\": rate limit quota exceeded"]}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should be returned as an ErrRateLimited", func() {
+		err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+		Expect(vaultkv.IsRateLimited(err)).To(BeTrue())
+
+		var limited *vaultkv.ErrRateLimited
+		Expect(errors.As(err, &limited)).To(BeTrue())
+		Expect(limited.RetryAfter).To(Equal(time.Second))
+	})
+
+	When("a RetryPolicy retrying 429s is set", func() {
+		BeforeEach(func() {
+			client.RetryPolicy = vaultkv.DefaultRetryPolicy()
+			client.RetryPolicy.MinBackoff = time.Millisecond
+		})
+
+		It("should retry even a non-idempotent request", func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(2))
+		})
+	})
+})
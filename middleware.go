@@ -0,0 +1,116 @@
+package vaultkv
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+//RequestInfo describes the Vault API request that an HTTP request is being
+// made for.
+type RequestInfo struct {
+	//Method is the HTTP method of the request
+	Method string
+	//Path is the Vault API path of the request, as given to Curl, such as
+	// "secret/foo". It does not include the /v1/ prefix.
+	Path string
+}
+
+//RoundTripFunc sends an HTTP request to the Vault and returns its response.
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+//Middleware wraps the sending of each HTTP request that a Client makes. It is
+// given the request, which it may modify or replace, and next, which sends the
+// request on through the rest of the chain. A Middleware may also return a
+// response or an error of its own without calling next. Errors returned from
+// the chain are returned to the caller as an *ErrTransport wrapping them, if
+// they are not one already.
+//
+//The error that Vault gave in a response can be decoded with ResponseError.
+// Middleware is called for each attempt of a request that is retried, and for
+// each node tried when a Cluster is in use.
+type Middleware func(info RequestInfo, req *http.Request, next RoundTripFunc) (*http.Response, error)
+
+//ResponseError returns the error that Vault gave in the given response, or nil
+// if the response has a 2xx status code. The body of the response is read and
+// replaced with one of the same content, so that it can still be read by the
+// rest of the request chain. Unlike the errors returned from the functions of
+// Client, an error from a 503 is always returned as an *APIError, because
+// finding out why the Vault is unavailable takes another request.
+func ResponseError(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+
+	body, err := readAndReplace(&resp.Body)
+	if err != nil {
+		return err
+	}
+
+	errorsStruct := apiError{}
+	//A body which isn't JSON just leaves the API error without messages
+	_ = json.Unmarshal(body, &errorsStruct)
+
+	api := newAPIError(resp, errorsStruct)
+	if err = api.specific(resp); err != nil {
+		return err
+	}
+
+	return api
+}
+
+//chain returns a RoundTripFunc which sends requests through the built-in and
+// user-given middleware of the Client, and then on to the given RoundTripFunc.
+func (v *Client) chain(info RequestInfo, send RoundTripFunc) RoundTripFunc {
+	middleware := make([]Middleware, 0, len(v.Middleware)+3)
+	middleware = append(middleware, v.limitMiddleware, v.namespaceMiddleware)
+	middleware = append(middleware, v.Middleware...)
+	middleware = append(middleware, v.traceMiddleware)
+
+	next := send
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next = bindMiddleware(middleware[i], info, next)
+	}
+
+	return func(req *http.Request) (*http.Response, error) {
+		resp, err := next(req)
+		if err != nil && !errors.As(err, new(*ErrTransport)) {
+			err = &ErrTransport{message: err.Error(), err: err}
+		}
+
+		return resp, err
+	}
+}
+
+func bindMiddleware(m Middleware, info RequestInfo, next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		return m(info, req, next)
+	}
+}
+
+//namespaceMiddleware sends the Namespace of the Client as the
+// X-Vault-Namespace header.
+func (v *Client) namespaceMiddleware(info RequestInfo, req *http.Request, next RoundTripFunc) (*http.Response, error) {
+	if v.Namespace != "" && !pathNamespaceBlacklisted(info.Path) {
+		req.Header.Set("X-Vault-Namespace", strings.Trim(v.Namespace, "/")+"/")
+	}
+
+	return next(req)
+}
+
+//traceMiddleware writes requests and responses to the Trace of the Client. It
+// is the innermost middleware, so that the trace shows the request as sent.
+func (v *Client) traceMiddleware(info RequestInfo, req *http.Request, next RoundTripFunc) (*http.Response, error) {
+	if v.Trace == nil {
+		return next(req)
+	}
+
+	v.traceRequest(req)
+	resp, err := next(req)
+	if err == nil {
+		v.traceResponse(resp)
+	}
+
+	return resp, err
+}
@@ -0,0 +1,128 @@
+package vaultkv_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Middleware", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var received http.Header
+	var status int
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		received = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+			} else {
+				_, _ = w.Write([]byte(`{"errors":["nope"]}`))
+			}
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("middleware adds a header", func() {
+		var calls []string
+		var seenInfo vaultkv.RequestInfo
+
+		BeforeEach(func() {
+			calls = nil
+			client.Namespace = "team"
+			client.Middleware = []vaultkv.Middleware{
+				func(info vaultkv.RequestInfo, req *http.Request, next vaultkv.RoundTripFunc) (*http.Response, error) {
+					calls = append(calls, "first")
+					seenInfo = info
+					req.Header.Set("X-Custom", "beep")
+					return next(req)
+				},
+				func(info vaultkv.RequestInfo, req *http.Request, next vaultkv.RoundTripFunc) (*http.Response, error) {
+					calls = append(calls, "second")
+					Expect(req.Header.Get("X-Custom")).To(Equal("beep"))
+					Expect(req.Header.Get("X-Vault-Namespace")).To(Equal("team/"))
+					return next(req)
+				},
+			}
+		})
+
+		It("should send the header, calling the middleware in order", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(received.Get("X-Custom")).To(Equal("beep"))
+			Expect(received.Get("X-Vault-Namespace")).To(Equal("team/"))
+			Expect(calls).To(Equal([]string{"first", "second"}))
+			Expect(seenInfo).To(Equal(vaultkv.RequestInfo{Method: "GET", Path: "secret/foo"}))
+		})
+	})
+
+	When("middleware injects a fault", func() {
+		injected := errors.New("injected fault")
+
+		BeforeEach(func() {
+			client.Middleware = []vaultkv.Middleware{
+				func(info vaultkv.RequestInfo, req *http.Request, next vaultkv.RoundTripFunc) (*http.Response, error) {
+					return nil, injected
+				},
+			}
+		})
+
+		It("should return the error as a transport error", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(vaultkv.IsTransport(err)).To(BeTrue())
+			Expect(errors.Is(err, injected)).To(BeTrue())
+			Expect(received).To(BeNil())
+		})
+	})
+
+	When("middleware inspects the error in the response", func() {
+		var decoded error
+
+		BeforeEach(func() {
+			status = http.StatusNotFound
+			client.Middleware = []vaultkv.Middleware{
+				func(info vaultkv.RequestInfo, req *http.Request, next vaultkv.RoundTripFunc) (*http.Response, error) {
+					resp, err := next(req)
+					if err == nil {
+						decoded = vaultkv.ResponseError(resp)
+					}
+					return resp, err
+				},
+			}
+		})
+
+		It("should decode the error, leaving the response readable", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(vaultkv.IsNotFound(decoded)).To(BeTrue())
+
+			var apiErr *vaultkv.APIError
+			Expect(errors.As(decoded, &apiErr)).To(BeTrue())
+			Expect(apiErr.Errors).To(Equal([]string{"nope"}))
+
+			By("the caller still getting the error")
+			Expect(vaultkv.IsNotFound(err)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("nope"))
+		})
+	})
+})
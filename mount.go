@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -22,6 +23,15 @@ type Mount struct {
 	Description string
 	Config      *MountConfig
 	Options     map[string]interface{}
+	//Accessor is the unique identifier Vault assigned to the mount. It is
+	// returned when listing mounts, and ignored when enabling them.
+	Accessor string
+	//Local mounts are not replicated to performance secondaries. This can only
+	// be set when the mount is enabled.
+	Local bool
+	//SealWrap enables seal wrapping of the mount's storage. This can only be set
+	// when the mount is enabled.
+	SealWrap bool
 }
 
 //MountConfig specifies configuration options given when initializing a backend.
@@ -37,6 +47,9 @@ type mountListAPI struct {
 	Description string                 `json:"description"`
 	Config      mountConfigListAPI     `json:"config"`
 	Options     map[string]interface{} `json:"options"`
+	Accessor    string                 `json:"accessor"`
+	Local       bool                   `json:"local"`
+	SealWrap    bool                   `json:"seal_wrap"`
 }
 
 func (m mountListAPI) Parse() Mount {
@@ -45,6 +58,9 @@ func (m mountListAPI) Parse() Mount {
 		Description: m.Description,
 		Config:      m.Config.Parse(),
 		Options:     m.Options,
+		Accessor:    m.Accessor,
+		Local:       m.Local,
+		SealWrap:    m.SealWrap,
 	}
 }
 
@@ -88,7 +104,7 @@ func newMountConfigEnableAPI(conf *MountConfig) *mountConfigEnableAPI {
 			if conf.MaxLeaseTTL == 0 {
 				return ""
 			}
-			return conf.DefaultLeaseTTL.String()
+			return conf.MaxLeaseTTL.String()
 		}(),
 		PluginName:   conf.PluginName,
 		ForceNoCache: conf.ForceNoCache,
@@ -99,11 +115,32 @@ func newMountConfigEnableAPI(conf *MountConfig) *mountConfigEnableAPI {
 // be seen with the current authentication token. It is returned as a map
 // of mount points to mount information.
 func (c *Client) ListMounts() (map[string]Mount, error) {
+	return c.ListMountsContext(context.Background())
+}
+
+//ListMountsContext is ListMounts with a context governing the request.
+func (c *Client) ListMountsContext(ctx context.Context) (map[string]Mount, error) {
+	return c.listMounts(ctx, "/sys/mounts")
+}
+
+//ListAuthMounts queries the Vault backend for a list of enabled auth backends
+// that can be seen with the current authentication token. It is returned as a
+// map of mount points, relative to /v1/auth, to mount information.
+func (c *Client) ListAuthMounts() (map[string]Mount, error) {
+	return c.ListAuthMountsContext(context.Background())
+}
+
+//ListAuthMountsContext is ListAuthMounts with a context governing the request.
+func (c *Client) ListAuthMountsContext(ctx context.Context) (map[string]Mount, error) {
+	return c.listMounts(ctx, "/sys/auth")
+}
+
+func (c *Client) listMounts(ctx context.Context, path string) (map[string]Mount, error) {
 	output := map[string]interface{}{}
 	//Prior to 1.10, the mount names were top level keys. Then, they duplicated the
 	// information into "data" with other metadata in the top level keys. So we need
 	// to check if the data key is there (and isn't just a mount name)
-	err := c.doRequest("GET", "/sys/mounts", nil, &output)
+	err := c.doRequest(ctx, "GET", path, nil, &output)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +151,7 @@ func (c *Client) ListMounts() (map[string]Mount, error) {
 	}
 
 	if mounts == nil {
-		mounts := getMountList(output)
+		mounts = getMountList(output)
 		if mounts == nil {
 			return nil, fmt.Errorf("Could not parse mount list")
 		}
@@ -178,24 +215,69 @@ func (o KVMountOptions) WithVersion(version int) KVMountOptions {
 //EnableSecretsMount mounts a secrets backend at the given path, configured with
 // the given Mount configuration.
 func (c *Client) EnableSecretsMount(path string, config Mount) error {
+	return c.EnableSecretsMountContext(context.Background(), path, config)
+}
+
+//EnableSecretsMountContext is EnableSecretsMount with a context governing the
+//request.
+func (c *Client) EnableSecretsMountContext(ctx context.Context, path string, config Mount) error {
+	return c.enableMount(ctx, fmt.Sprintf("/sys/mounts/%s", path), config)
+}
+
+//EnableAuthMount enables an auth backend at the given path, configured with
+// the given Mount configuration. The path is relative to /v1/auth, and so is the
+// mount given to the Auth functions of this client to log in against it.
+func (c *Client) EnableAuthMount(path string, config Mount) error {
+	return c.EnableAuthMountContext(context.Background(), path, config)
+}
+
+//EnableAuthMountContext is EnableAuthMount with a context governing the
+//request.
+func (c *Client) EnableAuthMountContext(ctx context.Context, path string, config Mount) error {
+	return c.enableMount(ctx, fmt.Sprintf("/sys/auth/%s", path), config)
+}
+
+func (c *Client) enableMount(ctx context.Context, path string, config Mount) error {
 	input := struct {
 		Type        string                `json:"type"`
 		Description string                `json:"description"`
 		Config      *mountConfigEnableAPI `json:"config,omitempty"`
 		Options     interface{}           `json:"options,omitempty"`
+		Local       bool                  `json:"local,omitempty"`
+		SealWrap    bool                  `json:"seal_wrap,omitempty"`
 	}{
 		Type:        config.Type,
 		Description: config.Description,
 		Config:      newMountConfigEnableAPI(config.Config),
 		Options:     config.Options,
+		Local:       config.Local,
+		SealWrap:    config.SealWrap,
 	}
 
-	return c.doRequest("POST", fmt.Sprintf("/sys/mounts/%s", path), &input, nil)
+	return c.doRequest(ctx, "POST", path, &input, nil)
 }
 
 //DisableSecretsMount deletes the mount at the given path.
 func (c *Client) DisableSecretsMount(path string) error {
-	return c.doRequest("DELETE", fmt.Sprintf("/sys/mounts/%s", path), nil, nil)
+	return c.DisableSecretsMountContext(context.Background(), path)
+}
+
+//DisableSecretsMountContext is DisableSecretsMount with a context governing
+//the request.
+func (c *Client) DisableSecretsMountContext(ctx context.Context, path string) error {
+	return c.doRequest(ctx, "DELETE", fmt.Sprintf("/sys/mounts/%s", path), nil, nil)
+}
+
+//DisableAuthMount disables the auth backend at the given path, revoking all
+// tokens that were issued through it.
+func (c *Client) DisableAuthMount(path string) error {
+	return c.DisableAuthMountContext(context.Background(), path)
+}
+
+//DisableAuthMountContext is DisableAuthMount with a context governing the
+//request.
+func (c *Client) DisableAuthMountContext(ctx context.Context, path string) error {
+	return c.doRequest(ctx, "DELETE", fmt.Sprintf("/sys/auth/%s", path), nil, nil)
 }
 
 //TuneMountOptions are parameters to be sent to the Vault when editing the
@@ -209,6 +291,27 @@ type TuneMountOptions struct {
 
 //TuneSecretsMount updates the configuration of the mount at the given path.
 func (c *Client) TuneSecretsMount(path string, opts TuneMountOptions) error {
+	return c.TuneSecretsMountContext(context.Background(), path, opts)
+}
+
+//TuneSecretsMountContext is TuneSecretsMount with a context governing the
+//request.
+func (c *Client) TuneSecretsMountContext(ctx context.Context, path string, opts TuneMountOptions) error {
+	return c.tuneMount(ctx, fmt.Sprintf("/sys/mounts/%s/tune", path), opts)
+}
+
+//TuneAuthMount updates the configuration of the auth backend at the given
+// path.
+func (c *Client) TuneAuthMount(path string, opts TuneMountOptions) error {
+	return c.TuneAuthMountContext(context.Background(), path, opts)
+}
+
+//TuneAuthMountContext is TuneAuthMount with a context governing the request.
+func (c *Client) TuneAuthMountContext(ctx context.Context, path string, opts TuneMountOptions) error {
+	return c.tuneMount(ctx, fmt.Sprintf("/sys/auth/%s/tune", path), opts)
+}
+
+func (c *Client) tuneMount(ctx context.Context, path string, opts TuneMountOptions) error {
 	rawTuneMountOptions := struct {
 		Description     string                 `json:"description,omitempty"`
 		DefaultLeaseTTL int                    `json:"default_lease_ttl,omitempty"`
@@ -221,8 +324,8 @@ func (c *Client) TuneSecretsMount(path string, opts TuneMountOptions) error {
 		Options:         opts.Options,
 	}
 
-	return c.doRequest("POST",
-		fmt.Sprintf("/sys/mounts/%s/tune", path),
+	return c.doRequest(ctx, "POST",
+		path,
 		rawTuneMountOptions,
 		nil,
 	)
@@ -232,7 +335,13 @@ func (c *Client) TuneSecretsMount(path string, opts TuneMountOptions) error {
 // version 2. Just a shorthand wrapper for TuneSecretsMount with the
 // appropriate opts structure.
 func (c *Client) UpgradeKVToV2(path string) error {
-	return c.TuneSecretsMount(
+	return c.UpgradeKVToV2Context(context.Background(), path)
+}
+
+//UpgradeKVToV2Context is UpgradeKVToV2 with a context governing the request.
+func (c *Client) UpgradeKVToV2Context(ctx context.Context, path string) error {
+	return c.TuneSecretsMountContext(
+		ctx,
 		path,
 		TuneMountOptions{
 			Options: KVMountOptions{}.WithVersion(2),
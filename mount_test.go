@@ -0,0 +1,115 @@
+package vaultkv_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mount", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var lock sync.Mutex
+	var body string
+	var sent map[string]interface{}
+
+	BeforeEach(func() {
+		sent = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			if r.Method != "GET" {
+				sent = map[string]interface{}{}
+				_ = json.NewDecoder(r.Body).Decode(&sent)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL:  serverURL,
+			AuthToken: "root",
+			Trace:     GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("EnableSecretsMount", func() {
+		JustBeforeEach(func() {
+			err = client.EnableSecretsMount("database", vaultkv.Mount{
+				Type: "database",
+				Config: &vaultkv.MountConfig{
+					DefaultLeaseTTL: time.Minute,
+					MaxLeaseTTL:     time.Hour,
+				},
+			})
+		})
+
+		It("should send each of the lease TTLs", func() {
+			Expect(err).NotTo(HaveOccurred())
+			lock.Lock()
+			defer lock.Unlock()
+			Expect(sent).To(HaveKey("config"))
+			Expect(sent["config"]).To(HaveKeyWithValue("default_lease_ttl", "1m0s"))
+			Expect(sent["config"]).To(HaveKeyWithValue("max_lease_ttl", "1h0m0s"))
+		})
+	})
+
+	Describe("ListMounts", func() {
+		var mounts map[string]vaultkv.Mount
+
+		JustBeforeEach(func() {
+			mounts, err = client.ListMounts()
+		})
+
+		When("the mounts are given under the data key", func() {
+			BeforeEach(func() {
+				body = `{
+					"request_id": "8c2a3f5e-0000-1111-2222-333344445555",
+					"secret/": {"type": "kv", "accessor": "kv_1234"},
+					"data": {
+						"secret/": {"type": "kv", "accessor": "kv_1234"}
+					}
+				}`
+			})
+
+			It("should return the mounts", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mounts).To(HaveKey("secret"))
+				Expect(mounts["secret"].Accessor).To(Equal("kv_1234"))
+			})
+		})
+
+		When("the mounts are given as top level keys, as by older Vaults", func() {
+			BeforeEach(func() {
+				body = `{
+					"secret/": {"type": "kv", "accessor": "kv_1234"},
+					"sys/": {"type": "system", "accessor": "system_5678"}
+				}`
+			})
+
+			It("should return the mounts", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(mounts).To(HaveLen(2))
+				Expect(mounts["secret"].Type).To(Equal("kv"))
+				Expect(mounts["sys"].Accessor).To(Equal("system_5678"))
+			})
+		})
+	})
+})
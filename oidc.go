@@ -1,35 +1,116 @@
-//go:build !windows
-
 package vaultkv
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
-	"syscall"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/cap/util"
 	"github.com/hashicorp/go-secure-stdlib/base62"
 )
 
-// AuthOIDCMetadata is the metadata member set by AuthOIDC
+// AuthOIDCMetadata holds the auth URL of an OIDC login. The Metadata of the
+// AuthOutput returned by an OIDC login is an AuthJWTMetadata, as the oidc and
+// jwt auth methods are one and the same.
 type AuthOIDCMetadata struct {
 	AuthURL string `json:"auth_url"`
 }
 
-// authHalts are the signals we want to interrupt our auth callback on.
-// SIGTSTP is omitted for Windows.
-var authHalts = []os.Signal{os.Interrupt, os.Kill, syscall.SIGTSTP}
+// OIDCLoginOptions configure the browser flow of AuthOIDCWithOptions. Each
+// member left empty takes the same default as the vault CLI.
+type OIDCLoginOptions struct {
+	// Role is the role to log in as. If empty, the default role of the mount
+	// is used.
+	Role string
+	// ListenAddress is the address the callback listener binds to. If empty,
+	// "localhost" is used.
+	ListenAddress string
+	// ListenPort is the port the callback listener binds to. If zero, 8250 is
+	// used. If negative, a free port is chosen, which only works if the role
+	// allows redirect URIs with any port.
+	ListenPort int
+	// CallbackScheme, CallbackHost and CallbackPort make up the redirect URI
+	// given to the OIDC provider, which must be allowed by the role. They
+	// default to "http", "localhost" and the port that is listened on, and
+	// need only be set if the browser reaches the listener by another name,
+	// such as through a proxy.
+	CallbackScheme string
+	CallbackHost   string
+	CallbackPort   int
+	// SkipBrowser, if true, prints the auth URL for the user to open
+	// themselves, rather than opening it in a browser.
+	SkipBrowser bool
+	// Timeout is how long to wait for the OIDC provider to call back. If zero,
+	// two minutes is used.
+	Timeout time.Duration
+	// SuccessHTML and ErrorHTML are the pages shown in the browser once the
+	// login succeeds or fails. If empty, simple pages are used.
+	SuccessHTML string
+	ErrorHTML   string
+	// Output is where instructions for the user are written. If nil,
+	// os.Stderr is used.
+	Output io.Writer
+}
+
+const (
+	defaultOIDCListenAddress = "localhost"
+	defaultOIDCPort          = 8250
+	defaultOIDCTimeout       = 2 * time.Minute
+)
+
+func (o OIDCLoginOptions) withDefaults() OIDCLoginOptions {
+	if o.ListenAddress == "" {
+		o.ListenAddress = defaultOIDCListenAddress
+	}
+	if o.ListenPort == 0 {
+		o.ListenPort = defaultOIDCPort
+	}
+	if o.ListenPort < 0 {
+		o.ListenPort = 0
+	}
+	if o.CallbackScheme == "" {
+		o.CallbackScheme = "http"
+	}
+	if o.CallbackHost == "" {
+		o.CallbackHost = "localhost"
+	}
+	if o.Timeout == 0 {
+		o.Timeout = defaultOIDCTimeout
+	}
+	if o.SuccessHTML == "" {
+		o.SuccessHTML = oidcSuccessHTML
+	}
+	if o.ErrorHTML == "" {
+		o.ErrorHTML = oidcErrorHTML
+	}
+	if o.Output == nil {
+		o.Output = os.Stderr
+	}
+
+	return o
+}
 
 // AuthOIDC is a shorthand for AuthOIDCMount against the default OIDC mountpoint,
-// 'OIDC'.
+// 'oidc'. The username and password are not used, as the login is completed in
+// the browser; they remain for compatibility. For logins without a browser, see
+// AuthJWT.
 func (v *Client) AuthOIDC(username, password string) (ret *AuthOutput, err error) {
-	return v.AuthOIDCMount("OIDC")
+	return v.AuthOIDCMount("oidc")
+}
+
+// AuthOIDCContext is AuthOIDC with a context governing the login.
+func (v *Client) AuthOIDCContext(ctx context.Context, username, password string) (ret *AuthOutput, err error) {
+	return v.AuthOIDCMountContext(ctx, "oidc")
 }
 
 type loginResponse struct {
@@ -37,81 +118,253 @@ type loginResponse struct {
 	err        error
 }
 
-// AuthOIDCMount submits the given username and password to the OIDC auth endpoint
-// mounted at the given mountpoint, checking it against existing OIDC auth
-// configurations. If auth is successful, then the AuthOutput object is returned,
-// and this client's AuthToken is set to the returned token. Given mountpoint is
-// relative to /v1/auth.
+// AuthOIDCMount is AuthOIDCWithOptions with the default options, which log in
+// as the default role of the mount, with a callback to localhost:8250.
 func (v *Client) AuthOIDCMount(mount string) (ret *AuthOutput, err error) {
+	return v.AuthOIDCMountContext(context.Background(), mount)
+}
+
+// AuthOIDCMountContext is AuthOIDCMount with a context governing the login.
+func (v *Client) AuthOIDCMountContext(ctx context.Context, mount string) (ret *AuthOutput, err error) {
+	return v.AuthOIDCWithOptionsContext(ctx, mount, OIDCLoginOptions{})
+}
+
+// AuthOIDCWithOptions logs in to the OIDC auth endpoint mounted at the given
+// mountpoint, by opening the auth URL of the OIDC provider in a browser and
+// waiting for it to call back to a listener with the result. If auth is
+// successful, then the AuthOutput object is returned, with Metadata of type
+// AuthJWTMetadata, and this client's AuthToken is set to the returned token.
+// Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthOIDCWithOptions(mount string, opts OIDCLoginOptions) (ret *AuthOutput, err error) {
+	return v.AuthOIDCWithOptionsContext(context.Background(), mount, opts)
+}
+
+// AuthOIDCWithOptionsContext is AuthOIDCWithOptions with a context governing
+// the login. If the context is cancelled while waiting for the provider
+// callback, the login is abandoned and the context's error is returned.
+func (v *Client) AuthOIDCWithOptionsContext(ctx context.Context, mount string, opts OIDCLoginOptions) (ret *AuthOutput, err error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+	opts = opts.withDefaults()
+
 	// handle ctrl-c while waiting for the callback
 	sigintCh := make(chan os.Signal, 1)
 	signal.Notify(sigintCh, authHalts...)
 	defer signal.Stop(sigintCh)
-	raw := &authOutputRaw{}
 
-	authURL, clientNonce, err := fetchAuthURL(v, mount)
+	listener, err := net.Listen("tcp", net.JoinHostPort(opts.ListenAddress, strconv.Itoa(opts.ListenPort)))
 	if err != nil {
 		return nil, err
 	}
-	doneCh := make(chan loginResponse)
-	http.HandleFunc("/oidc/callback", callbackHandler(v, mount, clientNonce, doneCh))
+	defer listener.Close()
+
+	callbackPort := opts.CallbackPort
+	if callbackPort == 0 {
+		callbackPort = listener.Addr().(*net.TCPAddr).Port
+	}
+	redirectURI := fmt.Sprintf("%s://%s/oidc/callback",
+		opts.CallbackScheme, net.JoinHostPort(opts.CallbackHost, strconv.Itoa(callbackPort)))
 
-	port := "8250"
-	listenAddress := "localhost"
-	listener, err := net.Listen("tcp", listenAddress+":"+port)
+	authURL, clientNonce, err := fetchAuthURL(ctx, v, mount, opts.Role, redirectURI)
 	if err != nil {
 		return nil, err
 	}
-	defer listener.Close()
 
-	fmt.Fprintf(os.Stderr, "Complete the login via your OIDC provider. Launching browser to:\n\n    %s\n\n\n", authURL)
-	if err := util.OpenURL(authURL); err != nil {
-		return nil, fmt.Errorf("failed to launch the browser , err=%w", err)
-	}
-	fmt.Fprintf(os.Stderr, "Waiting for OIDC authentication to complete...\n")
+	// The server is private to this login, so that logins can be made
+	// concurrently, or from a process with HTTP handlers of its own.
+	doneCh := make(chan loginResponse, 1)
+	mux := http.NewServeMux()
+	mux.Handle("/oidc/callback", callbackHandler(ctx, v, mount, clientNonce, opts, doneCh))
+	server := &http.Server{Handler: mux}
+	defer server.Close()
 
-	// Start local server
 	go func() {
-		err := http.Serve(listener, nil)
+		err := server.Serve(listener)
 		if err != nil && err != http.ErrServerClosed {
-			doneCh <- loginResponse{nil, err}
+			sendLoginResponse(doneCh, loginResponse{nil, err})
 		}
 	}()
-	// Wait for either the callback to finish, or a halt signal (e.g., SIGKILL, SIGINT, SIGTSTP) to be received or up to 2 minutes
+
+	if opts.SkipBrowser {
+		fmt.Fprintf(opts.Output, "Complete the login via your OIDC provider. Open the following link in your browser:\n\n    %s\n\n\n", authURL)
+	} else {
+		fmt.Fprintf(opts.Output, "Complete the login via your OIDC provider. Launching browser to:\n\n    %s\n\n\n", authURL)
+		if err := util.OpenURL(authURL); err != nil {
+			fmt.Fprintf(opts.Output, "Could not launch the browser (%s). Open the above link manually.\n\n", err)
+		}
+	}
+	fmt.Fprintf(opts.Output, "Waiting for OIDC authentication to complete...\n")
+
+	timer := time.NewTimer(opts.Timeout)
+	defer timer.Stop()
+
+	// Wait for either the callback to finish, or a halt signal (e.g., SIGKILL, SIGINT, SIGTSTP) to be received, or the timeout
 	select {
 	case s := <-doneCh:
 		return s.authOutput, s.err
 	case <-sigintCh:
 		return nil, errors.New("Interrupted")
-	case <-time.After(2 * time.Minute):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
 		return nil, errors.New("Timed out waiting for response from provider")
 	}
+}
+
+// OIDCManualLoginOptions configure AuthOIDCManual.
+type OIDCManualLoginOptions struct {
+	// Role is the role to log in as. If empty, the default role of the mount
+	// is used.
+	Role string
+	// RedirectURI is the redirect URI given to the OIDC provider, which must be
+	// allowed by the role. Nothing needs to be listening on it, as the browser
+	// is only expected to show an error page when it gets there. If empty,
+	// http://localhost:8250/oidc/callback is used.
+	RedirectURI string
+	// Prompt is called with the auth URL, and returns where the browser was
+	// redirected to after the login, which may be given as the full URL, or as
+	// just its query string holding the code and state. If nil, the auth URL
+	// is written to Output, and the redirect URL is read as a line from Input.
+	Prompt func(ctx context.Context, authURL string) (string, error)
+	// Input and Output are used by the default Prompt. If nil, os.Stdin and
+	// os.Stderr are used.
+	Input  io.Reader
+	Output io.Writer
+}
 
-	ret = raw.toFinal(AuthOIDCMetadata{})
-	v.AuthToken = ret.ClientToken
-	return
+// AuthOIDCManual logs in to the OIDC auth endpoint mounted at the given
+// mountpoint without a callback listener, for when the browser used to log in
+// cannot reach the machine that the login is for, such as over SSH. The user
+// opens the auth URL in any browser, and once logged in, copies the URL that
+// the browser was redirected to back to the Prompt, from which the login is
+// completed. If auth is successful, then the AuthOutput object is returned,
+// with Metadata of type AuthJWTMetadata, and this client's AuthToken is set to
+// the returned token. Given mountpoint is relative to /v1/auth.
+func (v *Client) AuthOIDCManual(mount string, opts OIDCManualLoginOptions) (ret *AuthOutput, err error) {
+	return v.AuthOIDCManualContext(context.Background(), mount, opts)
 }
-func fetchAuthURL(v *Client, mount string) (string, string, error) {
-	//var authURL string
 
+// AuthOIDCManualContext is AuthOIDCManual with a context governing the login.
+// If the context is cancelled while waiting for the Prompt, the login is
+// abandoned and the context's error is returned.
+func (v *Client) AuthOIDCManualContext(ctx context.Context, mount string, opts OIDCManualLoginOptions) (ret *AuthOutput, err error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	redirectURI := opts.RedirectURI
+	if redirectURI == "" {
+		redirectURI = fmt.Sprintf("http://%s/oidc/callback",
+			net.JoinHostPort(defaultOIDCListenAddress, strconv.Itoa(defaultOIDCPort)))
+	}
+
+	prompt := opts.Prompt
+	if prompt == nil {
+		prompt = stdioOIDCPrompt(opts.Input, opts.Output)
+	}
+
+	authURL, clientNonce, err := fetchAuthURL(ctx, v, mount, opts.Role, redirectURI)
+	if err != nil {
+		return nil, err
+	}
+
+	redirected, err := prompt(ctx, authURL)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := parseOIDCRedirect(redirected)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.oidcCallback(ctx, mount, clientNonce, params)
+}
+
+// stdioOIDCPrompt returns a prompt which writes the auth URL to the given
+// output and reads the redirect URL as a line from the given input.
+func stdioOIDCPrompt(input io.Reader, output io.Writer) func(context.Context, string) (string, error) {
+	if input == nil {
+		input = os.Stdin
+	}
+	if output == nil {
+		output = os.Stderr
+	}
+
+	return func(ctx context.Context, authURL string) (string, error) {
+		fmt.Fprintf(output, "Complete the login via your OIDC provider. Open the following link in your browser:\n\n    %s\n\n", authURL)
+		fmt.Fprintf(output, "Once logged in, your browser will fail to load a page. Paste the URL of that page here: ")
+
+		lines := make(chan loginLine, 1)
+		go func() {
+			line, err := bufio.NewReader(input).ReadString('\n')
+			if err == io.EOF && line != "" {
+				err = nil
+			}
+			lines <- loginLine{line, err}
+		}()
+
+		select {
+		case l := <-lines:
+			return l.line, l.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+type loginLine struct {
+	line string
+	err  error
+}
+
+// parseOIDCRedirect returns the parameters of the redirect URL, or query
+// string, that the OIDC provider sent the browser to.
+func parseOIDCRedirect(redirected string) (url.Values, error) {
+	redirected = strings.TrimSpace(redirected)
+	if i := strings.Index(redirected, "?"); i >= 0 {
+		redirected = redirected[i+1:]
+	}
+	if i := strings.Index(redirected, "#"); i >= 0 {
+		redirected = redirected[:i]
+	}
+
+	params, err := url.ParseQuery(redirected)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse the redirect URL: %s", err)
+	}
+
+	if providerErr := params.Get("error"); providerErr != "" {
+		return nil, fmt.Errorf("The OIDC provider returned an error: %s %s", providerErr, params.Get("error_description"))
+	}
+
+	if params.Get("state") == "" || (params.Get("code") == "" && params.Get("id_token") == "") {
+		return nil, fmt.Errorf("The redirect URL has no state and code")
+	}
+
+	return params, nil
+}
+
+func fetchAuthURL(ctx context.Context, v *Client, mount, role, redirectURI string) (string, string, error) {
 	clientNonce, err := base62.Random(20)
 	if err != nil {
 		return "", "", err
 	}
 
-	callbackPort := "8250"
-	callbackMethod := "http"
-	callbackHost := "localhost"
-	redirectURI := fmt.Sprintf("%s://%s:%s/oidc/callback", callbackMethod, callbackHost, callbackPort)
 	data := map[string]interface{}{
-		// only default role is supported
-		//"role":         role,
 		"redirect_uri": redirectURI,
 		"client_nonce": clientNonce,
 	}
+	if role != "" {
+		data["role"] = role
+	}
 	raw := &authOutputRaw{}
 
 	err = v.doRequest(
+		ctx,
 		"POST",
 		fmt.Sprintf("auth/%s/oidc/auth_url", mount),
 		data,
@@ -121,77 +374,111 @@ func fetchAuthURL(v *Client, mount string) (string, string, error) {
 		return "", "", err
 	}
 
-	authUrl := raw.Data["auth_url"].(string)
-	return authUrl, clientNonce, err
+	authURL, _ := raw.Data["auth_url"].(string)
+	if authURL == "" {
+		// Vault gives an empty URL rather than an error for a bad role or
+		// redirect URI
+		return "", "", fmt.Errorf("No auth URL returned. Check that the role exists and allows the redirect URI `%s'", redirectURI)
+	}
+
+	return authURL, clientNonce, nil
+}
+
+// oidcCallback completes a login with the parameters that the OIDC provider
+// gave in its redirect, and sets the token of the client.
+func (v *Client) oidcCallback(ctx context.Context, mount, clientNonce string, params url.Values) (*AuthOutput, error) {
+	raw := &authOutputRaw{}
+	query := url.Values{}
+	query.Add("state", params.Get("state"))
+	query.Add("code", params.Get("code"))
+	query.Add("id_token", params.Get("id_token"))
+	query.Add("client_nonce", clientNonce)
+	err := v.doRequest(
+		ctx,
+		"GET",
+		fmt.Sprintf("auth/%s/oidc/callback", mount),
+		query,
+		&raw,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := raw.toFinal(AuthJWTMetadata{})
+	if err != nil {
+		return nil, err
+	}
+	v.SetAuthToken(ret.ClientToken)
+	return ret, nil
 }
-func callbackHandler(v *Client, mount string, clientNonce string, doneCh chan<- loginResponse) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		var response string
-		var authOutput *AuthOutput
-		var err error
-		defer func() {
-			w.Write([]byte(response))
-			doneCh <- loginResponse{authOutput, err}
-		}()
 
+func callbackHandler(ctx context.Context, v *Client, mount string, clientNonce string, opts OIDCLoginOptions, doneCh chan<- loginResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
 		// TODO: consider checking for method for post for additional auth step if required
-		raw := &authOutputRaw{}
-		query := url.Values{}
-		query.Add("state", req.FormValue("state"))
-		query.Add("code", req.FormValue("code"))
-		query.Add("id_token", req.FormValue("id_token"))
-		query.Add("client_nonce", clientNonce)
-		err = v.doRequest(
-			"GET",
-			fmt.Sprintf("auth/%s/oidc/callback", mount),
-			query,
-			&raw,
-		)
-		authOutput = &AuthOutput{}
-		authOutput.ClientToken = raw.Auth.ClientToken
-
-		successHtml := `
-		<!DOCTYPE html>
-		<html lang="en">
-		<head>
-			<meta charset="UTF-8">
-			<meta name="viewport" content="width=device-width, initial-scale=1.0">
-			<style>
-				body {
-					font-family: Arial, sans-serif;
-					text-align: center;
-					padding: 50px;
-				}
-				h1 {
-					color: #4CAF50;
-				}
-				p {
-					color: #333;
-				}
-			</style>
-		</head>
-		<body>
-			<h1>Success!</h1>
-			<p>Your request was successful.</p>
-		</body>
-		</html>`
-		errorHtml := `
-		<!DOCTYPE html>
-		<html lang="en">
-		<head>
-		  <title>500 Internal Server Error</title>
-		</head>
-		<body>
-		  <h1>500 Internal Server Error</h1>
-		  <p>Something went wrong on our end. We're working on fixing it, and we'll be back as soon as possible.</p>
-		  <p>In the meantime, please try again later.</p>
-		</body>
-		</html>`
+		if err := req.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		authOutput, err := v.oidcCallback(ctx, mount, clientNonce, req.Form)
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err != nil {
-			fmt.Println("Error calling back to vault", err.Error())
-			response = errorHtml
+			// The error is returned from the login, so it is not printed here
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(opts.ErrorHTML))
 		} else {
-			response = successHtml
+			_, _ = w.Write([]byte(opts.SuccessHTML))
 		}
+
+		sendLoginResponse(doneCh, loginResponse{authOutput, err})
+	}
+}
+
+// sendLoginResponse gives the first response to the waiting login, and drops
+// any that come after it, such as from the callback page being reloaded.
+func sendLoginResponse(doneCh chan<- loginResponse, resp loginResponse) {
+	select {
+	case doneCh <- resp:
+	default:
 	}
 }
+
+const oidcSuccessHTML = `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<style>
+		body {
+			font-family: Arial, sans-serif;
+			text-align: center;
+			padding: 50px;
+		}
+		h1 {
+			color: #4CAF50;
+		}
+		p {
+			color: #333;
+		}
+	</style>
+</head>
+<body>
+	<h1>Success!</h1>
+	<p>Your request was successful.</p>
+</body>
+</html>`
+
+const oidcErrorHTML = `
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>500 Internal Server Error</title>
+</head>
+<body>
+  <h1>500 Internal Server Error</h1>
+  <p>Something went wrong on our end. We're working on fixing it, and we'll be back as soon as possible.</p>
+  <p>In the meantime, please try again later.</p>
+</body>
+</html>`
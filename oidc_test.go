@@ -0,0 +1,263 @@
+package vaultkv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AuthOIDCWithOptions", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var opts vaultkv.OIDCLoginOptions
+	var authURLRequest map[string]string
+	var redirectURIs chan string
+	var output *vaultkv.AuthOutput
+
+	BeforeEach(func() {
+		authURLRequest = nil
+		redirectURIs = make(chan string, 1)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v1/auth/oidc/oidc/auth_url":
+				Expect(json.NewDecoder(r.Body).Decode(&authURLRequest)).To(Succeed())
+				redirectURIs <- authURLRequest["redirect_uri"]
+				_, _ = w.Write([]byte(`{"data":{"auth_url":"https://idp.example.com/authorize?state=st"}}`))
+			case "/v1/auth/oidc/oidc/callback":
+				query := r.URL.Query()
+				if query.Get("state") != "st" || query.Get("code") != "abc" || query.Get("client_nonce") != authURLRequest["client_nonce"] {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"errors":["invalid state"]}`))
+					return
+				}
+
+				_, _ = w.Write([]byte(`{"auth":{"client_token":"s.oidc","policies":["dev"],"lease_duration":3600,"renewable":true,"metadata":{"role":"dev","email":"dev@example.com"}}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+
+		opts = vaultkv.OIDCLoginOptions{
+			Role:        "dev",
+			ListenPort:  -1,
+			SkipBrowser: true,
+			Timeout:     5 * time.Second,
+			SuccessHTML: "all done",
+			Output:      &bytes.Buffer{},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	//loginAndCallBack runs the login in the background, and makes the request to the
+	// callback that the browser would be redirected to with the given query
+	loginAndCallBack := func(query string) (page string) {
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(done)
+			output, err = client.AuthOIDCWithOptions("oidc", opts)
+		}()
+
+		var redirectURI string
+		Eventually(redirectURIs).Should(Receive(&redirectURI))
+
+		resp, err := http.Get(redirectURI + "?" + query)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(done).Should(BeClosed())
+		return string(body)
+	}
+
+	When("the provider calls back with a valid code", func() {
+		var page string
+		JustBeforeEach(func() {
+			page = loginAndCallBack("state=st&code=abc")
+		})
+
+		It("should ask for the auth URL of the role", func() {
+			Expect(authURLRequest["role"]).To(Equal("dev"))
+			Expect(authURLRequest["redirect_uri"]).To(MatchRegexp(`^http://localhost:\d+/oidc/callback$`))
+		})
+
+		It("should return the full auth output and set the token", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(Equal("all done"))
+			Expect(output.ClientToken).To(Equal("s.oidc"))
+			Expect(output.Policies).To(Equal([]string{"dev"}))
+			Expect(output.LeaseDuration).To(Equal(time.Hour))
+			Expect(output.Renewable).To(BeTrue())
+			Expect(output.Metadata).To(Equal(vaultkv.AuthJWTMetadata{
+				Role:          "dev",
+				ClaimMappings: map[string]string{"email": "dev@example.com"},
+			}))
+			Expect(client.AuthToken).To(Equal("s.oidc"))
+		})
+
+		It("should print the auth URL", func() {
+			Expect(opts.Output.(*bytes.Buffer).String()).To(ContainSubstring("https://idp.example.com/authorize?state=st"))
+		})
+
+		When("the callback host is overridden", func() {
+			BeforeEach(func() {
+				opts.CallbackHost = "127.0.0.1"
+			})
+
+			It("should use it in the redirect URI", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(authURLRequest["redirect_uri"]).To(MatchRegexp(`^http://127\.0\.0\.1:\d+/oidc/callback$`))
+			})
+		})
+	})
+
+	When("the provider calls back with an invalid state", func() {
+		var page string
+		JustBeforeEach(func() {
+			page = loginAndCallBack("state=wrong&code=abc")
+		})
+
+		It("should return the error and show the error page", func() {
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrBadRequest{}))
+			Expect(page).To(ContainSubstring("500 Internal Server Error"))
+			Expect(client.AuthToken).To(BeEmpty())
+		})
+	})
+
+	When("the provider never calls back", func() {
+		BeforeEach(func() {
+			opts.Timeout = 100 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			output, err = client.AuthOIDCWithOptions("oidc", opts)
+		})
+
+		It("should time out", func() {
+			Expect(err).To(MatchError(ContainSubstring("Timed out")))
+		})
+	})
+})
+
+var _ = Describe("AuthOIDCManual", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var opts vaultkv.OIDCManualLoginOptions
+	var authURLRequest map[string]string
+	var output *vaultkv.AuthOutput
+
+	BeforeEach(func() {
+		authURLRequest = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v1/auth/oidc/oidc/auth_url":
+				Expect(json.NewDecoder(r.Body).Decode(&authURLRequest)).To(Succeed())
+				_, _ = w.Write([]byte(`{"data":{"auth_url":"https://idp.example.com/authorize?state=st"}}`))
+			case "/v1/auth/oidc/oidc/callback":
+				query := r.URL.Query()
+				if query.Get("state") != "st" || query.Get("code") != "abc" || query.Get("client_nonce") != authURLRequest["client_nonce"] {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"errors":["invalid state"]}`))
+					return
+				}
+
+				_, _ = w.Write([]byte(`{"auth":{"client_token":"s.oidc","policies":["dev"],"lease_duration":3600}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+
+		opts = vaultkv.OIDCManualLoginOptions{Role: "dev"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		output, err = client.AuthOIDCManual("oidc", opts)
+	})
+
+	When("the redirect URL is given to the prompt function", func() {
+		var promptedWith string
+
+		BeforeEach(func() {
+			opts.Prompt = func(ctx context.Context, authURL string) (string, error) {
+				promptedWith = authURL
+				return "http://localhost:8250/oidc/callback?code=abc&state=st", nil
+			}
+		})
+
+		It("should prompt with the auth URL for the default redirect URI", func() {
+			Expect(promptedWith).To(Equal("https://idp.example.com/authorize?state=st"))
+			Expect(authURLRequest["redirect_uri"]).To(Equal("http://localhost:8250/oidc/callback"))
+			Expect(authURLRequest["role"]).To(Equal("dev"))
+		})
+
+		It("should complete the login", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.ClientToken).To(Equal("s.oidc"))
+			Expect(client.AuthToken).To(Equal("s.oidc"))
+		})
+	})
+
+	When("the query string is pasted on the input", func() {
+		var printed *bytes.Buffer
+
+		BeforeEach(func() {
+			printed = &bytes.Buffer{}
+			opts.Input = strings.NewReader("  state=st&code=abc\n")
+			opts.Output = printed
+		})
+
+		It("should print the auth URL and complete the login", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(printed.String()).To(ContainSubstring("https://idp.example.com/authorize?state=st"))
+			Expect(client.AuthToken).To(Equal("s.oidc"))
+		})
+	})
+
+	When("the pasted URL has no code", func() {
+		BeforeEach(func() {
+			opts.Input = strings.NewReader("http://localhost:8250/oidc/callback?error=access_denied\n")
+			opts.Output = &bytes.Buffer{}
+		})
+
+		It("should err without calling back to the Vault", func() {
+			Expect(err).To(MatchError(ContainSubstring("access_denied")))
+			Expect(client.AuthToken).To(BeEmpty())
+		})
+	})
+})
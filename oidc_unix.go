@@ -0,0 +1,11 @@
+//go:build !windows
+
+package vaultkv
+
+import (
+	"os"
+	"syscall"
+)
+
+// authHalts are the signals we want to interrupt our auth callback on.
+var authHalts = []os.Signal{os.Interrupt, os.Kill, syscall.SIGTSTP}
@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -47,23 +48,33 @@ type RekeyState struct {
 //returned containing the necessary state for submitting keys for this rekey
 //operation.
 func (v *Client) NewRekey(conf RekeyConfig) (*Rekey, error) {
-	err := v.rekeyStart(conf)
+	return v.NewRekeyContext(context.Background(), conf)
+}
+
+//NewRekeyContext is NewRekey with a context governing the requests.
+func (v *Client) NewRekeyContext(ctx context.Context, conf RekeyConfig) (*Rekey, error) {
+	err := v.rekeyStart(ctx, conf)
 	if err != nil {
-		err = v.correct500Error(err)
+		err = v.correct500Error(ctx, err)
 		return nil, err
 	}
 
-	return v.CurrentRekey()
+	return v.CurrentRekeyContext(ctx)
 }
 
 //CurrentRekey returns a *Rekey with the state necessary to continue a rekey
 // operation if one is in progress. If no rekey is in progress, *ErrNotFound
 // is returned and no *Rekey is returned.
 func (v *Client) CurrentRekey() (*Rekey, error) {
+	return v.CurrentRekeyContext(context.Background())
+}
+
+//CurrentRekeyContext is CurrentRekey with a context governing the request.
+func (v *Client) CurrentRekeyContext(ctx context.Context) (*Rekey, error) {
 	var state RekeyState
-	err := v.doSysRequest("GET", "/sys/rekey/init", nil, &state)
+	err := v.doSysRequest(ctx, "GET", "/sys/rekey/init", nil, &state)
 	if err != nil {
-		err = v.correct500Error(err)
+		err = v.correct500Error(ctx, err)
 		return nil, err
 	}
 
@@ -80,10 +91,10 @@ func (v *Client) CurrentRekey() (*Rekey, error) {
 //This is here because in Vault 0.10.3, a regression was introduced that causes
 // rekey operations against an uninitialized or sealed Vault to return a 500
 // instead of a 503
-func (v *Client) correct500Error(err error) error {
+func (v *Client) correct500Error(ctx context.Context, err error) error {
 	//Thanks, Vault 0.10.3
 	if _, is500 := err.(*ErrInternalServer); is500 {
-		tmpErr := v.Health(true)
+		tmpErr := v.HealthContext(ctx, true)
 		if _, isUninitialized := tmpErr.(*ErrUninitialized); isUninitialized {
 			err = tmpErr
 		} else if _, isSealed := tmpErr.(*ErrSealed); isSealed {
@@ -94,18 +105,28 @@ func (v *Client) correct500Error(err error) error {
 	return err
 }
 
-func (v *Client) rekeyStart(conf RekeyConfig) error {
-	return v.doSysRequest("PUT", "/sys/rekey/init", &conf, nil)
+func (v *Client) rekeyStart(ctx context.Context, conf RekeyConfig) error {
+	return v.doSysRequest(ctx, "PUT", "/sys/rekey/init", &conf, nil)
 }
 
 //Cancel tells Vault to forget about the current rekey operation
 func (r *Rekey) Cancel() error {
-	return r.client.RekeyCancel()
+	return r.CancelContext(context.Background())
+}
+
+//CancelContext is Cancel with a context governing the request.
+func (r *Rekey) CancelContext(ctx context.Context) error {
+	return r.client.RekeyCancelContext(ctx)
 }
 
 //RekeyCancel tells Vault to forget about the current rekey operation
 func (v *Client) RekeyCancel() error {
-	return v.doSysRequest("DELETE", "/sys/rekey/init", nil, nil)
+	return v.RekeyCancelContext(context.Background())
+}
+
+//RekeyCancelContext is RekeyCancel with a context governing the request.
+func (v *Client) RekeyCancelContext(ctx context.Context) error {
+	return v.doSysRequest(ctx, "DELETE", "/sys/rekey/init", nil, nil)
 }
 
 //Before 0.10, it was "no rekey in progress". In 0.10, the word barrier was added
@@ -122,9 +143,14 @@ var rekeyRegexp = regexp.MustCompile("no (barrier )?rekey in progress")
 //returned as true. To retrieve the new keys after submitting enough existing
 //keys, call Keys() on the Rekey object.
 func (r *Rekey) Submit(keys ...string) (done bool, err error) {
+	return r.SubmitContext(context.Background(), keys...)
+}
+
+//SubmitContext is Submit with a context governing the requests.
+func (r *Rekey) SubmitContext(ctx context.Context, keys ...string) (done bool, err error) {
 	for _, key := range keys {
 		var result interface{}
-		result, err = r.client.rekeySubmit(key, r.state.Nonce)
+		result, err = r.client.rekeySubmit(ctx, key, r.state.Nonce)
 		if err != nil {
 			if ebr, is400 := err.(*ErrBadRequest); is400 {
 				r.state.Progress = 0
@@ -138,7 +164,7 @@ func (r *Rekey) Submit(keys ...string) (done bool, err error) {
 			} else if iserv, is500 := err.(*ErrInternalServer); is500 {
 				if IsInternalServer(err) {
 					if strings.Contains(err.Error(), "message authentication failed") {
-						err = &ErrBadRequest{message: iserv.message}
+						err = &ErrBadRequest{message: iserv.message, api: iserv.api}
 					}
 				}
 			}
@@ -167,7 +193,7 @@ type rekeyKeys struct {
 	KeysBase64 []string `json:"keys_base64"`
 }
 
-func (v *Client) rekeySubmit(key string, nonce string) (ret interface{}, err error) {
+func (v *Client) rekeySubmit(ctx context.Context, key string, nonce string) (ret interface{}, err error) {
 	if key == "" {
 		err = fmt.Errorf("no key provided")
 		return
@@ -179,6 +205,7 @@ func (v *Client) rekeySubmit(key string, nonce string) (ret interface{}, err err
 
 	tempMap := make(map[string]interface{})
 	err = v.doSysRequest(
+		ctx,
 		"PUT",
 		"/sys/rekey/update",
 		&struct {
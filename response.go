@@ -0,0 +1,111 @@
+package vaultkv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//Response is the envelope that Vault returns around the data of a response.
+// Members which were absent from the response are left as their zero values.
+type Response struct {
+	RequestID     string
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+	//Warnings are messages from Vault about the request that did not cause it
+	// to fail, such as the use of a deprecated path or an ignored parameter.
+	Warnings []string
+	//WrapInfo is set if the response was wrapped. See RequestWrapped.
+	WrapInfo *WrapInfo
+	//Auth is set if the response contains a token, such as from a login. Its
+	// Metadata member is a map[string]interface{}.
+	Auth *AuthOutput
+}
+
+type responseAPI struct {
+	RequestID     string       `json:"request_id"`
+	LeaseID       string       `json:"lease_id"`
+	LeaseDuration int64        `json:"lease_duration"`
+	Renewable     bool         `json:"renewable"`
+	Warnings      []string     `json:"warnings"`
+	WrapInfo      *wrapInfoAPI `json:"wrap_info"`
+	Auth          *authAPI     `json:"auth"`
+	Data          interface{}  `json:"data"`
+}
+
+func (r responseAPI) Parse() *Response {
+	ret := &Response{
+		RequestID:     r.RequestID,
+		LeaseID:       r.LeaseID,
+		LeaseDuration: time.Duration(r.LeaseDuration) * time.Second,
+		Renewable:     r.Renewable,
+		Warnings:      r.Warnings,
+	}
+
+	if r.WrapInfo != nil {
+		ret.WrapInfo = r.WrapInfo.Parse()
+	}
+
+	if r.Auth != nil {
+		//Without a metadata type to decode into, toFinal cannot err
+		ret.Auth, _ = authOutputRaw{Auth: *r.Auth}.toFinal(nil)
+		if r.Auth.Metadata != nil {
+			ret.Auth.Metadata = r.Auth.Metadata
+		}
+	}
+
+	return ret
+}
+
+//Do makes a request with the given method against the given path, and returns
+// the envelope of the response. The "data" member of the response is decoded
+// into output using encoding/json.Unmarshal semantics. For GET requests, input
+// must be nil or a url.Values, which is sent as the query string. Otherwise,
+// input is encoded as JSON into the request body.
+//
+//Do is useful for making calls to endpoints which this package has no
+// function for, or when the request ID or warnings of a response are wanted.
+func (v *Client) Do(method, path string, input, output interface{}) (*Response, error) {
+	return v.DoContext(context.Background(), method, path, input, output)
+}
+
+//DoContext is Do with a context governing the request.
+func (v *Client) DoContext(ctx context.Context, method, path string, input, output interface{}) (*Response, error) {
+	if output != nil &&
+		reflect.ValueOf(output).Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("Do output target must be a pointer if non-nil")
+	}
+
+	if _, isValues := input.(url.Values); input != nil && !isValues && strings.ToUpper(method) == "GET" {
+		return nil, fmt.Errorf("Do input must be a url.Values for GET requests")
+	}
+
+	raw := responseAPI{Data: output}
+	err := v.doRequest(ctx, method, path, input, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Parse(), nil
+}
+
+//handleWarnings passes any warnings in the given response body to the
+// WarningHandler of the Client, if it has one.
+func (v *Client) handleWarnings(method, path string, body []byte) {
+	if v.WarningHandler == nil {
+		return
+	}
+
+	envelope := struct {
+		Warnings []string `json:"warnings"`
+	}{}
+
+	if json.Unmarshal(body, &envelope) == nil && len(envelope.Warnings) > 0 {
+		v.WarningHandler(method, path, envelope.Warnings)
+	}
+}
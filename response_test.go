@@ -0,0 +1,124 @@
+package vaultkv_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var body string
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Do", func() {
+		var resp *vaultkv.Response
+		var output map[string]string
+
+		BeforeEach(func() {
+			body = `{
+				"request_id": "8c2a3f5e-0000-1111-2222-333344445555",
+				"lease_id": "database/creds/app/abcd",
+				"lease_duration": 3600,
+				"renewable": true,
+				"warnings": ["Endpoint ignored these unrecognized parameters: [beep]"],
+				"data": {"username": "app"}
+			}`
+		})
+
+		JustBeforeEach(func() {
+			output = map[string]string{}
+			resp, err = client.Do("GET", "database/creds/app", url.Values{"beep": []string{"boop"}}, &output)
+		})
+
+		It("should return the envelope and decode the data", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"username": "app"}))
+			Expect(resp.RequestID).To(Equal("8c2a3f5e-0000-1111-2222-333344445555"))
+			Expect(resp.LeaseID).To(Equal("database/creds/app/abcd"))
+			Expect(resp.LeaseDuration).To(Equal(time.Hour))
+			Expect(resp.Renewable).To(BeTrue())
+			Expect(resp.Warnings).To(ConsistOf("Endpoint ignored these unrecognized parameters: [beep]"))
+			Expect(resp.WrapInfo).To(BeNil())
+			Expect(resp.Auth).To(BeNil())
+		})
+
+		When("the response contains auth", func() {
+			BeforeEach(func() {
+				body = `{
+					"auth": {
+						"client_token": "s.abcdef",
+						"accessor": "acc",
+						"policies": ["default"],
+						"renewable": true,
+						"lease_duration": 60,
+						"metadata": {"role": "app"}
+					}
+				}`
+			})
+
+			It("should populate Auth", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Auth).NotTo(BeNil())
+				Expect(resp.Auth.ClientToken).To(Equal("s.abcdef"))
+				Expect(resp.Auth.Accessor).To(Equal("acc"))
+				Expect(resp.Auth.Policies).To(Equal([]string{"default"}))
+				Expect(resp.Auth.LeaseDuration).To(Equal(time.Minute))
+				Expect(resp.Auth.Metadata).To(Equal(map[string]interface{}{"role": "app"}))
+			})
+		})
+
+		When("the output is not a pointer", func() {
+			It("should err", func() {
+				_, err = client.Do("GET", "secret/foo", nil, map[string]string{})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Describe("WarningHandler", func() {
+		var warnings []string
+		var warnedPath string
+
+		BeforeEach(func() {
+			warnings = nil
+			body = `{"warnings": ["the path secret/foo is deprecated"], "data": {"foo": "bar"}}`
+			client.WarningHandler = func(method, path string, w []string) {
+				warnedPath = path
+				warnings = append(warnings, w...)
+			}
+		})
+
+		It("should be given the warnings of any call", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnedPath).To(Equal("secret/foo"))
+			Expect(warnings).To(ConsistOf("the path secret/foo is deprecated"))
+		})
+	})
+
+})
@@ -0,0 +1,154 @@
+package vaultkv
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//RetryPolicy configures how a Client retries requests that fail in ways that
+// are likely to be transient, such as during a Vault leader election. If the
+// RetryPolicy on a Client is nil, every request is attempted exactly once.
+type RetryPolicy struct {
+	//MaxAttempts is the total number of times a request may be made, including
+	// the first attempt. Values less than 2 disable retries.
+	MaxAttempts int
+	//MinBackoff is the time waited before the first retry. Each subsequent retry
+	// waits twice as long as the one before it, up to MaxBackoff.
+	MinBackoff time.Duration
+	//MaxBackoff caps the time waited between any two attempts. If zero, waits
+	// are not capped.
+	MaxBackoff time.Duration
+	//Jitter is the fraction, between 0 and 1, of each wait that is randomized
+	// so that many clients failing at once do not retry in lockstep.
+	Jitter float64
+	//StatusCodes are the HTTP response codes which are considered retryable.
+	StatusCodes []int
+	//RetryTransportErrors causes requests that did not get a response at all
+	// to be retried. Requests ended by their context are never retried.
+	RetryTransportErrors bool
+	//Methods are the HTTP methods which are safe to retry. If nil, GET, HEAD,
+	// OPTIONS and DELETE are retried. Vault treats PUT as an alias for POST, and
+	// some PUT endpoints (such as /sys/unseal) are not idempotent, so neither is
+	// retried by default. Requests rejected by a rate limit quota were never
+	// acted on, so if 429 is in StatusCodes, they are retried whatever their
+	// method.
+	Methods []string
+}
+
+var defaultRetryMethods = []string{"GET", "HEAD", "OPTIONS", "DELETE"}
+
+//DefaultRetryPolicy returns a RetryPolicy which retries idempotent requests up
+// to three times in total on transport errors and on the status codes that
+// Vault returns while a cluster is failing over or overloaded.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  250 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		Jitter:      0.2,
+		StatusCodes: []int{
+			http.StatusPreconditionFailed,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryTransportErrors: true,
+	}
+}
+
+func (r *RetryPolicy) methodRetryable(method string) bool {
+	methods := r.Methods
+	if methods == nil {
+		methods = defaultRetryMethods
+	}
+
+	for _, m := range methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//shouldRetry returns true if a request which has been made attempt times and
+// resulted in the given response or error should be made again.
+func (r *RetryPolicy) shouldRetry(ctx context.Context, method string, attempt int, resp *http.Response, err error) bool {
+	if r == nil || attempt >= r.MaxAttempts || ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return r.RetryTransportErrors && IsTransport(err) && r.methodRetryable(method)
+	}
+
+	for _, code := range r.StatusCodes {
+		if resp.StatusCode == code {
+			return code == http.StatusTooManyRequests || r.methodRetryable(method)
+		}
+	}
+
+	return false
+}
+
+//backoff returns how long to wait after the given attempt has failed. A
+// Retry-After header given in seconds is respected if it asks for a longer
+// wait than the policy would, so long as it is within MaxBackoff.
+func (r *RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	//Without a MaxBackoff, doubling must still stop before the wait overflows,
+	// however many attempts the policy allows
+	limit := r.MaxBackoff
+	if limit <= 0 {
+		limit = math.MaxInt64
+	}
+
+	wait := r.MinBackoff
+	for i := 1; i < attempt && wait > 0 && wait < limit; i++ {
+		if wait > limit/2 {
+			wait = limit
+			break
+		}
+
+		wait *= 2
+	}
+
+	if wait > limit {
+		wait = limit
+	}
+
+	if r.Jitter > 0 {
+		wait -= time.Duration(r.Jitter * rand.Float64() * float64(wait))
+	}
+
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			after := time.Duration(secs) * time.Second
+			if after > wait && (r.MaxBackoff == 0 || after <= r.MaxBackoff) {
+				wait = after
+			}
+		}
+	}
+
+	return wait
+}
+
+//wait blocks for the backoff period following the given attempt. If the
+// context ends first, an ErrTransport wrapping the context's error is returned.
+func (r *RetryPolicy) wait(ctx context.Context, attempt int, resp *http.Response) error {
+	timer := time.NewTimer(r.backoff(attempt, resp))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return &ErrTransport{message: ctx.Err().Error(), err: ctx.Err()}
+	}
+}
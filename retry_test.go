@@ -0,0 +1,159 @@
+package vaultkv_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RetryPolicy", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var requests int32
+	//failures is the number of requests the server will fail before succeeding
+	var failures int32
+	var failStatus int
+
+	BeforeEach(func() {
+		requests = 0
+		failures = 0
+		failStatus = http.StatusBadGateway
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if atomic.AddInt32(&requests, 1) <= atomic.LoadInt32(&failures) {
+				w.WriteHeader(failStatus)
+				_, _ = w.Write([]byte(`{"errors":["try again later"]}`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"data":{"foo":"bar"}}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			VaultURL: serverURL,
+			Trace:    GinkgoWriter,
+			RetryPolicy: &vaultkv.RetryPolicy{
+				MaxAttempts:          3,
+				MinBackoff:           time.Millisecond,
+				MaxBackoff:           10 * time.Millisecond,
+				Jitter:               0.5,
+				StatusCodes:          []int{http.StatusBadGateway},
+				RetryTransportErrors: true,
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("a GET fails fewer times than the max attempts", func() {
+		BeforeEach(func() {
+			failures = 2
+		})
+
+		It("should retry until the request succeeds", func() {
+			output := map[string]string{}
+			err = client.Get("secret/foo", &output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(Equal(map[string]string{"foo": "bar"}))
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(3))
+		})
+	})
+
+	When("a GET fails more times than the max attempts", func() {
+		BeforeEach(func() {
+			failures = 5
+		})
+
+		It("should give up after the max attempts", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(3))
+		})
+	})
+
+	When("the status code is not retryable", func() {
+		BeforeEach(func() {
+			failures = 1
+			failStatus = http.StatusBadRequest
+		})
+
+		It("should not retry", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrBadRequest{}))
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(1))
+		})
+	})
+
+	When("the method is not idempotent", func() {
+		BeforeEach(func() {
+			failures = 1
+		})
+
+		It("should not retry", func() {
+			err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+			Expect(err).To(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(1))
+		})
+
+		Context("but the policy allows the method", func() {
+			BeforeEach(func() {
+				client.RetryPolicy.Methods = []string{"PUT"}
+			})
+
+			It("should retry", func() {
+				err = client.Set("secret/foo", map[string]string{"foo": "bar"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(2))
+			})
+		})
+	})
+
+	When("there is no retry policy", func() {
+		BeforeEach(func() {
+			failures = 1
+			client.RetryPolicy = nil
+		})
+
+		It("should make only one attempt", func() {
+			err = client.Get("secret/foo", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(1))
+		})
+	})
+
+	When("the context ends while waiting to retry", func() {
+		BeforeEach(func() {
+			failures = 5
+			client.RetryPolicy.MinBackoff = time.Minute
+			client.RetryPolicy.MaxBackoff = time.Minute
+		})
+
+		It("should stop retrying and return a transport error", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			err = client.GetContext(ctx, "secret/foo", nil)
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrTransport{}))
+			Expect(atomic.LoadInt32(&requests)).To(BeEquivalentTo(1))
+		})
+	})
+
+	When("the server cannot be reached", func() {
+		It("should retry transport errors", func() {
+			server.Close()
+			err = client.Get("secret/foo", nil)
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrTransport{}))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
@@ -40,6 +41,12 @@ type GenerateRootState struct {
 
 //NewGenerateRoot initializes and returns a new generate root object.
 func (v *Client) NewGenerateRoot() (*GenerateRoot, error) {
+	return v.NewGenerateRootContext(context.Background())
+}
+
+//NewGenerateRootContext is NewGenerateRoot with a context governing the
+//requests.
+func (v *Client) NewGenerateRootContext(ctx context.Context) (*GenerateRoot, error) {
 	ret := GenerateRoot{
 		client: v,
 		otp:    make([]byte, 16),
@@ -58,12 +65,12 @@ func (v *Client) NewGenerateRoot() (*GenerateRoot, error) {
 	// a confusing transport error...
 	// Generating a root token should be infrequent enough that we can tolerate
 	// the speed hit that an extra round trip causes.
-	healthErr := v.Health(true)
+	healthErr := v.HealthContext(ctx, true)
 	if IsUninitialized(healthErr) || IsSealed(healthErr) {
 		return nil, healthErr
 	}
 
-	err = v.doRequest("PUT", "/sys/generate-root/attempt",
+	err = v.doRequest(ctx, "PUT", "/sys/generate-root/attempt",
 		map[string]string{"otp": string(base64OTP)}, &ret.state)
 	if err != nil && !IsBadRequest(err) {
 		return nil, err
@@ -71,7 +78,7 @@ func (v *Client) NewGenerateRoot() (*GenerateRoot, error) {
 
 	if ret.state.OTPLength != 0 || IsBadRequest(err) {
 		//Then we need to let the API generate the root token
-		err = ret.Cancel()
+		err = ret.CancelContext(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -79,7 +86,7 @@ func (v *Client) NewGenerateRoot() (*GenerateRoot, error) {
 		//In 0.11.2 and 0.11.3, you can't provide an empty body or else Vault EOFs.
 		// So you have to give an empty string otp to prompt Vault to make an otp
 		// of the proper length for you. This was fixed in 0.11.4.
-		err = v.doRequest("PUT", "/sys/generate-root/attempt",
+		err = v.doRequest(ctx, "PUT", "/sys/generate-root/attempt",
 			map[string]string{"otp": ""}, &ret.state)
 		if err != nil {
 			return nil, err
@@ -105,8 +112,13 @@ var genRootRegexp = regexp.MustCompile("no root generation in progress")
 //new keys after submitting enough existing keys, call RootToken() on the
 //GenerateRoot object.
 func (g *GenerateRoot) Submit(keys ...string) (done bool, err error) {
+	return g.SubmitContext(context.Background(), keys...)
+}
+
+//SubmitContext is Submit with a context governing the requests.
+func (g *GenerateRoot) SubmitContext(ctx context.Context, keys ...string) (done bool, err error) {
 	for _, key := range keys {
-		g.state, err = g.client.genRootSubmit(key, g.state.Nonce)
+		g.state, err = g.client.genRootSubmit(ctx, key, g.state.Nonce)
 		if err != nil {
 			if ebr, is400 := err.(*ErrBadRequest); is400 {
 				g.state.Progress = 0
@@ -132,16 +144,28 @@ func (g *GenerateRoot) Submit(keys ...string) (done bool, err error) {
 
 //Cancel cancels the current generate root operation
 func (g *GenerateRoot) Cancel() error {
-	return g.client.GenerateRootCancel()
+	return g.CancelContext(context.Background())
+}
+
+//CancelContext is Cancel with a context governing the request.
+func (g *GenerateRoot) CancelContext(ctx context.Context) error {
+	return g.client.GenerateRootCancelContext(ctx)
 }
 
 //GenerateRootCancel cancels the current generate root operation
 func (v *Client) GenerateRootCancel() error {
-	return v.doSysRequest("DELETE", "/sys/generate-root/attempt", nil, nil)
+	return v.GenerateRootCancelContext(context.Background())
+}
+
+//GenerateRootCancelContext is GenerateRootCancel with a context governing the
+//request.
+func (v *Client) GenerateRootCancelContext(ctx context.Context) error {
+	return v.doSysRequest(ctx, "DELETE", "/sys/generate-root/attempt", nil, nil)
 }
 
-func (v *Client) genRootSubmit(key string, nonce string) (ret GenerateRootState, err error) {
+func (v *Client) genRootSubmit(ctx context.Context, key string, nonce string) (ret GenerateRootState, err error) {
 	err = v.doSysRequest(
+		ctx,
 		"PUT",
 		"/sys/generate-root/update",
 		&struct {
@@ -1,6 +1,7 @@
 package vaultkv
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
@@ -9,13 +10,14 @@ import (
 )
 
 func (v *Client) doSysRequest(
+	ctx context.Context,
 	method, path string,
 	input interface{},
 	output interface{}) error {
-	err := v.doRequest(method, path, input, output)
+	err := v.doRequest(ctx, method, path, input, output)
 	//In sys contexts, 400 can mean that the Vault is uninitialized.
 	if _, is400 := err.(*ErrBadRequest); is400 {
-		initialized, initErr := v.IsInitialized()
+		initialized, initErr := v.IsInitializedContext(withoutWrapping(ctx))
 		if initErr != nil {
 			return initErr
 		}
@@ -30,9 +32,15 @@ func (v *Client) doSysRequest(
 
 //IsInitialized returns true if the targeted Vault is initialized
 func (v *Client) IsInitialized() (is bool, err error) {
+	return v.IsInitializedContext(context.Background())
+}
+
+//IsInitializedContext is IsInitialized with a context governing the request.
+func (v *Client) IsInitializedContext(ctx context.Context) (is bool, err error) {
 	//Don't call doSysRequest from here because it calls IsInitialized
 	// and that could get ugly
 	err = v.doRequest(
+		ctx,
 		"GET",
 		"/sys/init",
 		nil,
@@ -68,7 +76,13 @@ type SealState struct {
 
 //SealStatus calls the /sys/seal-status endpoint and returns the info therein
 func (v *Client) SealStatus() (ret *SealState, err error) {
+	return v.SealStatusContext(context.Background())
+}
+
+//SealStatusContext is SealStatus with a context governing the request.
+func (v *Client) SealStatusContext(ctx context.Context) (ret *SealState, err error) {
 	err = v.doSysRequest(
+		ctx,
 		"GET",
 		"/sys/seal-status",
 		nil,
@@ -101,8 +115,13 @@ type InitVaultOutput struct {
 //unseal endpoint. If any of the unseal calls are unsuccessful, an error is
 //returned.
 func (i *InitVaultOutput) Unseal() error {
+	return i.UnsealContext(context.Background())
+}
+
+//UnsealContext is Unseal with a context governing the unseal requests.
+func (i *InitVaultOutput) UnsealContext(ctx context.Context) error {
 	for _, key := range i.Keys {
-		sealState, err := i.client.Unseal(key)
+		sealState, err := i.client.UnsealContext(ctx, key)
 		if err != nil {
 			return err
 		}
@@ -120,8 +139,14 @@ func (i *InitVaultOutput) Unseal() error {
 // object is automatically set to the root token if the init is successful.
 //If the vault has already been initialized, this returns *ErrBadRequest
 func (v *Client) InitVault(in InitConfig) (out *InitVaultOutput, err error) {
+	return v.InitVaultContext(context.Background(), in)
+}
+
+//InitVaultContext is InitVault with a context governing the request.
+func (v *Client) InitVaultContext(ctx context.Context, in InitConfig) (out *InitVaultOutput, err error) {
 	out = &InitVaultOutput{}
 	err = v.doSysRequest(
+		ctx,
 		"PUT",
 		"/sys/init",
 		&in,
@@ -129,7 +154,7 @@ func (v *Client) InitVault(in InitConfig) (out *InitVaultOutput, err error) {
 	)
 
 	if err == nil {
-		v.AuthToken = out.RootToken
+		v.SetAuthToken(out.RootToken)
 	}
 
 	out.client = v
@@ -144,7 +169,12 @@ func (v *Client) InitVault(in InitConfig) (out *InitVaultOutput, err error) {
 // if the Vault is uninitialized or already sealed. This function squelches
 // these errors for consistency with earlier versions of Vault
 func (v *Client) Seal() error {
-	err := v.doSysRequest("PUT", "/sys/seal", nil, nil)
+	return v.SealContext(context.Background())
+}
+
+//SealContext is Seal with a context governing the request.
+func (v *Client) SealContext(ctx context.Context) error {
+	err := v.doSysRequest(ctx, "PUT", "/sys/seal", nil, nil)
 	if err != nil && (IsUninitialized(err) || IsSealed(err)) {
 		err = nil
 	}
@@ -158,8 +188,14 @@ func (v *Client) Seal() error {
 //formatted, an *ErrBadRequest is returned. If the vault is already unsealed,
 //no error is returned
 func (v *Client) Unseal(key string) (out *SealState, err error) {
+	return v.UnsealContext(context.Background(), key)
+}
+
+//UnsealContext is Unseal with a context governing the request.
+func (v *Client) UnsealContext(ctx context.Context, key string) (out *SealState, err error) {
 	out = &SealState{}
 	err = v.doSysRequest(
+		ctx,
 		"PUT",
 		"/sys/unseal",
 		&struct {
@@ -170,9 +206,10 @@ func (v *Client) Unseal(key string) (out *SealState, err error) {
 		&out,
 	)
 
-	if IsInternalServer(err) {
+	var iserv *ErrInternalServer
+	if errors.As(err, &iserv) {
 		if strings.Contains(err.Error(), "message authentication failed") {
-			err = &ErrBadRequest{message: err.Error()}
+			err = &ErrBadRequest{message: err.Error(), api: iserv.api}
 		}
 	}
 
@@ -183,7 +220,13 @@ func (v *Client) Unseal(key string) (out *SealState, err error) {
 //an unseal becomes 0. If the vault is unsealed, nothing happens and no error
 //is returned.
 func (v *Client) ResetUnseal() (err error) {
+	return v.ResetUnsealContext(context.Background())
+}
+
+//ResetUnsealContext is ResetUnseal with a context governing the request.
+func (v *Client) ResetUnsealContext(ctx context.Context) (err error) {
 	err = v.doSysRequest(
+		ctx,
 		"PUT",
 		"/sys/unseal",
 		&struct {
@@ -205,6 +248,12 @@ func (v *Client) ResetUnseal() (err error) {
 //is initialized but sealed, then ErrSealed will be returned. If none of these
 //are the case, no error is returned.
 func (v *Client) Health(standbyok bool) error {
+	return v.HealthContext(context.Background(), standbyok)
+}
+
+//HealthContext is Health with a context governing the request.
+func (v *Client) HealthContext(ctx context.Context, standbyok bool) error {
+	ctx = withoutWrapping(ctx)
 	//Don't call doRequest from Health because ParseError calls Health
 	query := url.Values{}
 	if standbyok {
@@ -212,10 +261,11 @@ func (v *Client) Health(standbyok bool) error {
 		query.Add("perfstandbyok", "true")
 	}
 
-	resp, err := v.Curl("GET", "/sys/health", query, nil)
+	resp, err := v.CurlContext(ctx, "GET", "/sys/health", query, nil)
 	if err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
 	errorsStruct := apiError{}
 	err = json.NewDecoder(resp.Body).Decode(&errorsStruct)
@@ -228,22 +278,23 @@ func (v *Client) Health(standbyok bool) error {
 	}
 
 	errorMessage := strings.Join(errorsStruct.Errors, "\n")
+	api := newAPIError(resp, errorsStruct)
 
 	switch resp.StatusCode {
 	case 200:
 		err = nil
 	case 429:
-		err = &ErrStandby{message: errorMessage}
+		err = &ErrStandby{message: errorMessage, api: api}
 	case 472:
-		err = &ErrDRSecondary{message: errorMessage}
+		err = &ErrDRSecondary{message: errorMessage, api: api}
 	case 473:
-		err = &ErrPerfStandby{message: errorMessage}
+		err = &ErrPerfStandby{message: errorMessage, api: api}
 	case 501:
-		err = &ErrUninitialized{message: errorMessage}
+		err = &ErrUninitialized{message: errorMessage, api: api}
 	case 503:
-		err = &ErrSealed{message: errorMessage}
+		err = &ErrSealed{message: errorMessage, api: api}
 	default:
-		err = errors.New(errorMessage)
+		err = api
 	}
 
 	return err
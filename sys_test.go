@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"strings"
+	"time"
 
 	"github.com/cloudfoundry-community/vaultkv"
 	. "github.com/onsi/ginkgo"
@@ -603,6 +604,116 @@ var _ = Describe("Sys", func() {
 		})
 	})
 
+	Describe("AuthMount", func() {
+		var testAuthName string
+		var testAuthConfig vaultkv.Mount
+
+		JustBeforeEach(func() {
+			InitAndUnsealVault()
+			err = vault.EnableAuthMount(testAuthName, testAuthConfig)
+		})
+
+		BeforeEach(func() {
+			testAuthName = "beepboop"
+			testAuthConfig = vaultkv.Mount{
+				Type:        "userpass",
+				Description: "a test auth mount",
+			}
+		})
+
+		Describe("Enabling a non-existent auth backend type", func() {
+			BeforeEach(func() {
+				testAuthConfig.Type = "dcgeduceohdursaoceh"
+			})
+
+			It("should return ErrBadRequest", AssertErrorOfType(&vaultkv.ErrBadRequest{}))
+		})
+
+		Describe("Enabling a userpass backend", func() {
+			It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+			Describe("Listing the auth backends", func() {
+				var authList map[string]vaultkv.Mount
+				JustBeforeEach(func() {
+					authList, err = vault.ListAuthMounts()
+				})
+
+				It("should show the new backend in the list", func() {
+					By("not erroring")
+					Expect(err).NotTo(HaveOccurred())
+
+					By("having a backend with the correct name")
+					backend, ok := authList[testAuthName]
+					Expect(ok).To(BeTrue())
+
+					By("having that backend display the correct type")
+					Expect(backend.Type).To(Equal(testAuthConfig.Type))
+
+					By("having that backend display the correct description")
+					Expect(backend.Description).To(Equal(testAuthConfig.Description))
+
+					By("not showing the token backend under a trailing slash")
+					Expect(authList).To(HaveKey("token"))
+				})
+			})
+
+			Describe("Tuning the backend", func() {
+				JustBeforeEach(func() {
+					err = vault.TuneAuthMount(testAuthName, vaultkv.TuneMountOptions{
+						DefaultLeaseTTL: 10 * time.Minute,
+						MaxLeaseTTL:     time.Hour,
+					})
+				})
+
+				It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+				Describe("Listing the auth backends", func() {
+					var authList map[string]vaultkv.Mount
+					JustBeforeEach(func() {
+						authList, err = vault.ListAuthMounts()
+					})
+
+					It("should show the new configuration", func() {
+						By("not erroring")
+						Expect(err).NotTo(HaveOccurred())
+
+						backend, ok := authList[testAuthName]
+						Expect(ok).To(BeTrue())
+
+						By("having the new lease TTLs")
+						Expect(backend.Config).NotTo(BeNil())
+						Expect(backend.Config.DefaultLeaseTTL).To(Equal(10 * time.Minute))
+						Expect(backend.Config.MaxLeaseTTL).To(Equal(time.Hour))
+					})
+				})
+			})
+
+			Describe("Disabling the backend", func() {
+				JustBeforeEach(func() {
+					err = vault.DisableAuthMount(testAuthName)
+				})
+
+				It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+				Describe("Listing the auth backends", func() {
+					var authList map[string]vaultkv.Mount
+					JustBeforeEach(func() {
+						authList, err = vault.ListAuthMounts()
+					})
+
+					It("should provide a list that has the backend gone", func() {
+						By("not erroring")
+						Expect(err).NotTo(HaveOccurred())
+
+						By("having the mount not be present")
+						_, ok := authList[testAuthName]
+						Expect(ok).To(BeFalse())
+					})
+				})
+			})
+		})
+	})
+
 	Describe("Health", func() {
 		JustBeforeEach(func() {
 			err = vault.Health(true)
@@ -0,0 +1,250 @@
+package vaultkv
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//TLSConfig describes the TLS settings used to connect to the Vault. If the
+// TLS member of a Client is set and its Client member is nil, the Client
+// builds its own HTTP client with a transport using these settings.
+type TLSConfig struct {
+	//CACert is the path to a file of PEM-encoded CA certificates used to verify
+	// the Vault's certificate. If neither CACert nor CAPath is given, the system
+	// certificate pool is used.
+	CACert string
+	//CAPath is the path to a directory of files of PEM-encoded CA certificates
+	// used to verify the Vault's certificate.
+	CAPath string
+	//ClientCert is the path to a PEM-encoded certificate to present to the
+	// Vault, such as for the cert auth method or a listener requiring mTLS.
+	ClientCert string
+	//ClientKey is the path to the PEM-encoded private key for ClientCert
+	ClientKey string
+	//ServerName overrides the host name used to verify the Vault's
+	// certificate, and is sent as the SNI host.
+	ServerName string
+	//InsecureSkipVerify disables all verification of the Vault's certificate.
+	InsecureSkipVerify bool
+	//MinVersion is the minimum TLS version to negotiate, as one of the
+	// tls.VersionTLS* constants. If zero, TLS 1.2 is used.
+	MinVersion uint16
+	//ReloadCertificates, if true, causes the CA and client certificate files to
+	// be checked for modification whenever a new connection is made, and
+	// reloaded if they have changed. This allows certificates to be rotated on
+	// disk without restarting the process.
+	ReloadCertificates bool
+}
+
+//ClientConfig loads the files referenced by the TLSConfig and returns a
+// *tls.Config which uses them.
+func (t *TLSConfig) ClientConfig() (*tls.Config, error) {
+	ret := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		MinVersion:         t.MinVersion,
+	}
+
+	if ret.MinVersion == 0 {
+		ret.MinVersion = tls.VersionTLS12
+	}
+
+	if (t.ClientCert == "") != (t.ClientKey == "") {
+		return nil, fmt.Errorf("both a client certificate and client key must be given")
+	}
+
+	files := &tlsFiles{conf: *t}
+	err := files.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.ReloadCertificates {
+		ret.RootCAs = files.pool
+		if files.cert != nil {
+			ret.Certificates = []tls.Certificate{*files.cert}
+		}
+
+		return ret, nil
+	}
+
+	if t.ClientCert != "" {
+		ret.GetClientCertificate = files.getClientCertificate
+	}
+
+	if files.pool != nil && !t.InsecureSkipVerify {
+		//The pool used for verification can't be swapped out in a tls.Config
+		// once it is in use, so verification is done by hand against whichever
+		// pool is current.
+		ret.InsecureSkipVerify = true
+		ret.VerifyConnection = files.verifyConnection
+	}
+
+	return ret, nil
+}
+
+//Transport returns a new *http.Transport with the same defaults as
+// http.DefaultTransport, but whose TLS settings come from the TLSConfig.
+func (t *TLSConfig) Transport() (*http.Transport, error) {
+	tlsConfig, err := t.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	ret := http.DefaultTransport.(*http.Transport).Clone()
+	ret.TLSClientConfig = tlsConfig
+	return ret, nil
+}
+
+//tlsFiles holds the certificates loaded from the files named by a TLSConfig
+// and reloads them when those files change.
+type tlsFiles struct {
+	conf TLSConfig
+
+	lock     sync.Mutex
+	modTimes map[string]time.Time
+	pool     *x509.CertPool
+	cert     *tls.Certificate
+}
+
+//paths returns every file that certificates are loaded from.
+func (f *tlsFiles) paths() ([]string, error) {
+	ret := []string{}
+	for _, path := range []string{f.conf.CACert, f.conf.ClientCert, f.conf.ClientKey} {
+		if path != "" {
+			ret = append(ret, path)
+		}
+	}
+
+	if f.conf.CAPath != "" {
+		files, err := ioutil.ReadDir(f.conf.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read CA directory `%s': %s", f.conf.CAPath, err)
+		}
+
+		for _, file := range files {
+			if !file.IsDir() {
+				ret = append(ret, filepath.Join(f.conf.CAPath, file.Name()))
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+//changed returns true if any of the files have been added, removed, or
+// modified since the last load.
+func (f *tlsFiles) changed() bool {
+	paths, err := f.paths()
+	if err != nil || len(paths) != len(f.modTimes) {
+		return true
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(f.modTimes[path]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f *tlsFiles) load() error {
+	paths, err := f.paths()
+	if err != nil {
+		return err
+	}
+
+	modTimes := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("Could not stat `%s': %s", path, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+
+	var pool *x509.CertPool
+	if f.conf.CACert != "" || f.conf.CAPath != "" {
+		pool = x509.NewCertPool()
+		for _, path := range paths {
+			if path == f.conf.ClientCert || path == f.conf.ClientKey {
+				continue
+			}
+
+			err = addCAFile(pool, path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var cert *tls.Certificate
+	if f.conf.ClientCert != "" {
+		keyPair, err := tls.LoadX509KeyPair(f.conf.ClientCert, f.conf.ClientKey)
+		if err != nil {
+			return fmt.Errorf("Could not load client certificate: %s", err)
+		}
+		cert = &keyPair
+	}
+
+	f.modTimes, f.pool, f.cert = modTimes, pool, cert
+	return nil
+}
+
+//reload reloads the files if they have changed. If the new files cannot be
+// loaded, the previously loaded certificates continue to be used.
+func (f *tlsFiles) reload() (*x509.CertPool, *tls.Certificate) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.changed() {
+		_ = f.load()
+	}
+
+	return f.pool, f.cert
+}
+
+func (f *tlsFiles) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	_, cert := f.reload()
+	return cert, nil
+}
+
+func (f *tlsFiles) verifyConnection(state tls.ConnectionState) error {
+	pool, _ := f.reload()
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("Vault presented no certificates")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       state.ServerName,
+		Roots:         pool,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func addCAFile(pool *x509.CertPool, path string) error {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Could not read CA certificate `%s': %s", path, err)
+	}
+
+	if !pool.AppendCertsFromPEM(contents) {
+		return fmt.Errorf("No PEM-encoded certificates found in `%s'", path)
+	}
+
+	return nil
+}
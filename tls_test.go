@@ -0,0 +1,144 @@
+package vaultkv_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+//writeUnrelatedCA writes a self-signed certificate that did not sign the test
+// Vault's certificate to the given path.
+func writeUnrelatedCA(path string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "not the vault ca"},
+		NotBefore:    time.Now().Add(-time.Second),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+var _ = Describe("TLSConfig", func() {
+	var client *vaultkv.Client
+	var tlsConfig *vaultkv.TLSConfig
+	var tmpDir string
+
+	BeforeEach(func() {
+		tmpDir, err = ioutil.TempDir("", "vaultkv-test-tls")
+		Expect(err).NotTo(HaveOccurred())
+
+		tlsConfig = &vaultkv.TLSConfig{CACert: certLocation}
+		client = &vaultkv.Client{
+			VaultURL: vaultURI,
+			TLS:      tlsConfig,
+			Trace:    GinkgoWriter,
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	JustBeforeEach(func() {
+		err = client.Health(true)
+	})
+
+	When("the CA certificate signed the Vault's certificate", func() {
+		It("should connect to the Vault", func() {
+			Expect(vaultkv.IsUninitialized(err)).To(BeTrue())
+		})
+	})
+
+	When("the CA certificate did not sign the Vault's certificate", func() {
+		BeforeEach(func() {
+			tlsConfig.CACert = filepath.Join(tmpDir, "ca.pem")
+			writeUnrelatedCA(tlsConfig.CACert)
+		})
+
+		It("should return a transport error", AssertErrorOfType(&vaultkv.ErrTransport{}))
+
+		When("the server name is overridden with one the certificate is not valid for", func() {
+			BeforeEach(func() {
+				tlsConfig.CACert = certLocation
+				tlsConfig.ServerName = "vault.example.com"
+			})
+
+			It("should return a transport error", AssertErrorOfType(&vaultkv.ErrTransport{}))
+		})
+	})
+
+	When("the CA certificate file does not exist", func() {
+		BeforeEach(func() {
+			tlsConfig.CACert = filepath.Join(tmpDir, "nope.pem")
+		})
+
+		It("should err", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("only a client certificate is given", func() {
+		BeforeEach(func() {
+			tlsConfig.ClientCert = certLocation
+		})
+
+		It("should err", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("a client key pair is given", func() {
+		BeforeEach(func() {
+			tlsConfig.ClientCert = certLocation
+			tlsConfig.ClientKey = keyLocation
+		})
+
+		It("should connect to the Vault", func() {
+			Expect(vaultkv.IsUninitialized(err)).To(BeTrue())
+		})
+	})
+
+	When("certificates are reloaded and the CA is rotated on disk", func() {
+		BeforeEach(func() {
+			tlsConfig.CACert = filepath.Join(tmpDir, "ca.pem")
+			tlsConfig.ReloadCertificates = true
+			writeUnrelatedCA(tlsConfig.CACert)
+		})
+
+		It("should start trusting the new CA without rebuilding the client", func() {
+			By("failing to verify the Vault before rotation")
+			Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrTransport{}))
+
+			By("writing the correct CA in place of the old one")
+			cert, err := ioutil.ReadFile(certLocation)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(tlsConfig.CACert, cert, 0644)
+			Expect(err).NotTo(HaveOccurred())
+			future := time.Now().Add(time.Minute)
+			Expect(os.Chtimes(tlsConfig.CACert, future, future)).To(Succeed())
+
+			By("verifying the Vault after rotation")
+			err = client.Health(true)
+			Expect(vaultkv.IsUninitialized(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,468 @@
+package vaultkv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//TokenCreateOptions are the parameters of a new token made with TokenCreate
+// and its variants. Only non-empty values are sent, so that Vault's defaults,
+// or those of the role, apply to the rest.
+type TokenCreateOptions struct {
+	//ID is the ID of the new token. If empty, Vault generates one. Only root
+	// tokens may set it.
+	ID string
+	//Policies are the policies of the new token. If empty, the new token has
+	// the policies of the token creating it, or those of the role.
+	Policies []string
+	//Meta is metadata which is attached to the token and shown in the audit
+	// log.
+	Meta map[string]string
+	//NoParent makes the token an orphan, which is not revoked when its parent
+	// is. Only root or sudo tokens may set it. See TokenCreateOrphan.
+	NoParent bool
+	//NoDefaultPolicy stops the default policy from being attached to the token.
+	NoDefaultPolicy bool
+	//NotRenewable stops the token from being renewed past its initial TTL.
+	NotRenewable bool
+	TTL          time.Duration
+	//ExplicitMaxTTL is a TTL past which the token cannot be renewed, regardless
+	// of the max TTLs of the system and mount.
+	ExplicitMaxTTL time.Duration
+	//Period makes the token periodic, such that each renewal gives it this TTL,
+	// and it has no max TTL.
+	Period      time.Duration
+	DisplayName string
+	//NumUses is the number of requests the token may make before it is revoked.
+	// Zero means unlimited.
+	NumUses int
+	//EntityAlias is the name of the entity alias to attach the token to. It
+	// requires a role that allows it.
+	EntityAlias string
+	//Type is "service" or "batch". If empty, the default of the role or of
+	// the mount is used.
+	Type string
+}
+
+type tokenCreateAPI struct {
+	ID              string            `json:"id,omitempty"`
+	Policies        []string          `json:"policies,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"`
+	NoParent        bool              `json:"no_parent,omitempty"`
+	NoDefaultPolicy bool              `json:"no_default_policy,omitempty"`
+	Renewable       *bool             `json:"renewable,omitempty"`
+	TTL             string            `json:"ttl,omitempty"`
+	ExplicitMaxTTL  string            `json:"explicit_max_ttl,omitempty"`
+	Period          string            `json:"period,omitempty"`
+	DisplayName     string            `json:"display_name,omitempty"`
+	NumUses         int               `json:"num_uses,omitempty"`
+	EntityAlias     string            `json:"entity_alias,omitempty"`
+	Type            string            `json:"type,omitempty"`
+}
+
+func (o TokenCreateOptions) toAPI() tokenCreateAPI {
+	ret := tokenCreateAPI{
+		ID:              o.ID,
+		Policies:        o.Policies,
+		Meta:            o.Meta,
+		NoParent:        o.NoParent,
+		NoDefaultPolicy: o.NoDefaultPolicy,
+		TTL:             durationSeconds(o.TTL),
+		ExplicitMaxTTL:  durationSeconds(o.ExplicitMaxTTL),
+		Period:          durationSeconds(o.Period),
+		DisplayName:     o.DisplayName,
+		NumUses:         o.NumUses,
+		EntityAlias:     o.EntityAlias,
+		Type:            o.Type,
+	}
+
+	if o.NotRenewable {
+		renewable := false
+		ret.Renewable = &renewable
+	}
+
+	return ret
+}
+
+//durationSeconds formats a duration as a number of seconds as understood by
+// Vault, or as the empty string if it is zero.
+func durationSeconds(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%ds", int64(d/time.Second))
+}
+
+//TokenCreate creates a child token of the token of the Client with the given
+// options. The Metadata of the returned AuthOutput is a map[string]string of
+// the metadata of the token. The token of the Client is not changed.
+func (v *Client) TokenCreate(opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	return v.TokenCreateContext(context.Background(), opts)
+}
+
+//TokenCreateContext is TokenCreate with a context governing the request.
+func (v *Client) TokenCreateContext(ctx context.Context, opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	return v.tokenCreate(ctx, "/auth/token/create", opts)
+}
+
+//TokenCreateOrphan creates a token with no parent, such that it is not revoked
+// when the token of the Client is. Unlike setting NoParent, this only requires
+// the token of the Client to have sudo access to the create-orphan endpoint.
+func (v *Client) TokenCreateOrphan(opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	return v.TokenCreateOrphanContext(context.Background(), opts)
+}
+
+//TokenCreateOrphanContext is TokenCreateOrphan with a context governing the
+// request.
+func (v *Client) TokenCreateOrphanContext(ctx context.Context, opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	return v.tokenCreate(ctx, "/auth/token/create-orphan", opts)
+}
+
+//TokenCreateWithRole creates a token against the token role with the given
+// name, which constrains the options that may be given, and supplies defaults
+// for those that are not. See TokenRoleWrite.
+func (v *Client) TokenCreateWithRole(role string, opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	return v.TokenCreateWithRoleContext(context.Background(), role, opts)
+}
+
+//TokenCreateWithRoleContext is TokenCreateWithRole with a context governing
+// the request.
+func (v *Client) TokenCreateWithRoleContext(ctx context.Context, role string, opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	role = strings.Trim(role, "/")
+	if role == "" {
+		return nil, fmt.Errorf("no role given")
+	}
+
+	return v.tokenCreate(ctx, fmt.Sprintf("/auth/token/create/%s", role), opts)
+}
+
+func (v *Client) tokenCreate(ctx context.Context, path string, opts TokenCreateOptions) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+	err = v.doRequest(ctx, "POST", path, opts.toAPI(), raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal(map[string]string{})
+}
+
+//TokenLookup returns information about the given token. The token of the
+// Client must have access to the lookup endpoint.
+func (v *Client) TokenLookup(token string) (ret *TokenInfo, err error) {
+	return v.TokenLookupContext(context.Background(), token)
+}
+
+//TokenLookupContext is TokenLookup with a context governing the request.
+func (v *Client) TokenLookupContext(ctx context.Context, token string) (ret *TokenInfo, err error) {
+	raw := tokenInfoRaw{}
+	err = v.doRequest(ctx, "POST", "/auth/token/lookup", struct {
+		Token string `json:"token"`
+	}{
+		Token: token,
+	}, &raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal()
+}
+
+//TokenLookupAccessor returns information about the token with the given
+// accessor. The ID of the returned TokenInfo is empty, as the token itself
+// cannot be found out from its accessor.
+func (v *Client) TokenLookupAccessor(accessor string) (ret *TokenInfo, err error) {
+	return v.TokenLookupAccessorContext(context.Background(), accessor)
+}
+
+//TokenLookupAccessorContext is TokenLookupAccessor with a context governing
+// the request.
+func (v *Client) TokenLookupAccessorContext(ctx context.Context, accessor string) (ret *TokenInfo, err error) {
+	raw := tokenInfoRaw{}
+	err = v.doRequest(ctx, "POST", "/auth/token/lookup-accessor", struct {
+		Accessor string `json:"accessor"`
+	}{
+		Accessor: accessor,
+	}, &raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal()
+}
+
+//TokenRenew renews the lease of the given token, asking for the given
+// increment from now to be added to it, and returns the resulting lease. An
+// increment of zero asks for the token's default TTL.
+func (v *Client) TokenRenew(token string, increment time.Duration) (ret *AuthOutput, err error) {
+	return v.TokenRenewContext(context.Background(), token, increment)
+}
+
+//TokenRenewContext is TokenRenew with a context governing the request.
+func (v *Client) TokenRenewContext(ctx context.Context, token string, increment time.Duration) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+	err = v.doRequest(ctx, "POST", "/auth/token/renew", struct {
+		Token     string `json:"token"`
+		Increment int64  `json:"increment,omitempty"`
+	}{
+		Token:     token,
+		Increment: int64(increment / time.Second),
+	}, raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal(map[string]string{})
+}
+
+//TokenRenewAccessor is TokenRenew for the token with the given accessor. The
+// ClientToken of the returned AuthOutput is empty.
+func (v *Client) TokenRenewAccessor(accessor string, increment time.Duration) (ret *AuthOutput, err error) {
+	return v.TokenRenewAccessorContext(context.Background(), accessor, increment)
+}
+
+//TokenRenewAccessorContext is TokenRenewAccessor with a context governing the
+// request.
+func (v *Client) TokenRenewAccessorContext(ctx context.Context, accessor string, increment time.Duration) (ret *AuthOutput, err error) {
+	raw := &authOutputRaw{}
+	err = v.doRequest(ctx, "POST", "/auth/token/renew-accessor", struct {
+		Accessor  string `json:"accessor"`
+		Increment int64  `json:"increment,omitempty"`
+	}{
+		Accessor:  accessor,
+		Increment: int64(increment / time.Second),
+	}, raw)
+	if err != nil {
+		return
+	}
+
+	return raw.toFinal(map[string]string{})
+}
+
+//TokenRevokeSelf revokes the token of the Client, along with all of its
+// children. The token of the Client is not cleared.
+func (v *Client) TokenRevokeSelf() error {
+	return v.TokenRevokeSelfContext(context.Background())
+}
+
+//TokenRevokeSelfContext is TokenRevokeSelf with a context governing the
+// request.
+func (v *Client) TokenRevokeSelfContext(ctx context.Context) error {
+	return v.doRequest(ctx, "POST", "/auth/token/revoke-self", nil, nil)
+}
+
+//TokenRevoke revokes the given token, along with all of its children.
+func (v *Client) TokenRevoke(token string) error {
+	return v.TokenRevokeContext(context.Background(), token)
+}
+
+//TokenRevokeContext is TokenRevoke with a context governing the request.
+func (v *Client) TokenRevokeContext(ctx context.Context, token string) error {
+	return v.doRequest(ctx, "POST", "/auth/token/revoke", struct {
+		Token string `json:"token"`
+	}{
+		Token: token,
+	}, nil)
+}
+
+//TokenRevokeAccessor revokes the token with the given accessor, along with all
+// of its children.
+func (v *Client) TokenRevokeAccessor(accessor string) error {
+	return v.TokenRevokeAccessorContext(context.Background(), accessor)
+}
+
+//TokenRevokeAccessorContext is TokenRevokeAccessor with a context governing
+// the request.
+func (v *Client) TokenRevokeAccessorContext(ctx context.Context, accessor string) error {
+	return v.doRequest(ctx, "POST", "/auth/token/revoke-accessor", struct {
+		Accessor string `json:"accessor"`
+	}{
+		Accessor: accessor,
+	}, nil)
+}
+
+//TokenRevokeOrphan revokes the given token, but not its children, which become
+// orphans. This requires sudo access to the revoke-orphan endpoint.
+func (v *Client) TokenRevokeOrphan(token string) error {
+	return v.TokenRevokeOrphanContext(context.Background(), token)
+}
+
+//TokenRevokeOrphanContext is TokenRevokeOrphan with a context governing the
+// request.
+func (v *Client) TokenRevokeOrphanContext(ctx context.Context, token string) error {
+	return v.doRequest(ctx, "POST", "/auth/token/revoke-orphan", struct {
+		Token string `json:"token"`
+	}{
+		Token: token,
+	}, nil)
+}
+
+//TokenListAccessors returns the accessors of all of the tokens in the Vault.
+// This requires sudo access to the accessors endpoint.
+func (v *Client) TokenListAccessors() ([]string, error) {
+	return v.TokenListAccessorsContext(context.Background())
+}
+
+//TokenListAccessorsContext is TokenListAccessors with a context governing the
+// request.
+func (v *Client) TokenListAccessorsContext(ctx context.Context) ([]string, error) {
+	return v.ListContext(ctx, "/auth/token/accessors")
+}
+
+//TokenRole is the configuration of a token role, which constrains and gives
+// defaults to the tokens created against it with TokenCreateWithRole.
+type TokenRole struct {
+	//Name is set by TokenRoleRead, and ignored by TokenRoleWrite.
+	Name string
+	//AllowedPolicies are the policies that tokens created against the role may
+	// have. If empty, they may have any of the policies of the token creating
+	// them.
+	AllowedPolicies []string
+	//DisallowedPolicies are policies that tokens created against the role may
+	// never have.
+	DisallowedPolicies []string
+	//Orphan causes tokens created against the role to have no parent.
+	Orphan bool
+	//Renewable allows tokens created against the role to be renewed. Vault
+	// makes roles renewable by default, but as every member is written by
+	// TokenRoleWrite, it must be set here for a renewable role.
+	Renewable bool
+	//PathSuffix is appended to the path of tokens created against the role, so
+	// that they can be revoked by prefix.
+	PathSuffix           string
+	AllowedEntityAliases []string
+	TokenTTL             time.Duration
+	TokenMaxTTL          time.Duration
+	TokenExplicitMaxTTL  time.Duration
+	TokenPeriod          time.Duration
+	TokenBoundCIDRs      []string
+	TokenNoDefaultPolicy bool
+	TokenNumUses         int
+	//TokenType is "service", "batch", "default-service" or "default-batch".
+	TokenType string
+}
+
+type tokenRoleAPI struct {
+	Name                 string   `json:"name,omitempty"`
+	AllowedPolicies      []string `json:"allowed_policies"`
+	DisallowedPolicies   []string `json:"disallowed_policies"`
+	Orphan               bool     `json:"orphan"`
+	Renewable            bool     `json:"renewable"`
+	PathSuffix           string   `json:"path_suffix"`
+	AllowedEntityAliases []string `json:"allowed_entity_aliases"`
+	TokenTTL             int64    `json:"token_ttl"`
+	TokenMaxTTL          int64    `json:"token_max_ttl"`
+	TokenExplicitMaxTTL  int64    `json:"token_explicit_max_ttl"`
+	TokenPeriod          int64    `json:"token_period"`
+	TokenBoundCIDRs      []string `json:"token_bound_cidrs"`
+	TokenNoDefaultPolicy bool     `json:"token_no_default_policy"`
+	TokenNumUses         int      `json:"token_num_uses"`
+	TokenType            string   `json:"token_type,omitempty"`
+}
+
+func (r TokenRole) toAPI() tokenRoleAPI {
+	return tokenRoleAPI{
+		AllowedPolicies:      r.AllowedPolicies,
+		DisallowedPolicies:   r.DisallowedPolicies,
+		Orphan:               r.Orphan,
+		Renewable:            r.Renewable,
+		PathSuffix:           r.PathSuffix,
+		AllowedEntityAliases: r.AllowedEntityAliases,
+		TokenTTL:             int64(r.TokenTTL / time.Second),
+		TokenMaxTTL:          int64(r.TokenMaxTTL / time.Second),
+		TokenExplicitMaxTTL:  int64(r.TokenExplicitMaxTTL / time.Second),
+		TokenPeriod:          int64(r.TokenPeriod / time.Second),
+		TokenBoundCIDRs:      r.TokenBoundCIDRs,
+		TokenNoDefaultPolicy: r.TokenNoDefaultPolicy,
+		TokenNumUses:         r.TokenNumUses,
+		TokenType:            r.TokenType,
+	}
+}
+
+func (r tokenRoleAPI) Parse() *TokenRole {
+	return &TokenRole{
+		Name:                 r.Name,
+		AllowedPolicies:      r.AllowedPolicies,
+		DisallowedPolicies:   r.DisallowedPolicies,
+		Orphan:               r.Orphan,
+		Renewable:            r.Renewable,
+		PathSuffix:           r.PathSuffix,
+		AllowedEntityAliases: r.AllowedEntityAliases,
+		TokenTTL:             time.Duration(r.TokenTTL) * time.Second,
+		TokenMaxTTL:          time.Duration(r.TokenMaxTTL) * time.Second,
+		TokenExplicitMaxTTL:  time.Duration(r.TokenExplicitMaxTTL) * time.Second,
+		TokenPeriod:          time.Duration(r.TokenPeriod) * time.Second,
+		TokenBoundCIDRs:      r.TokenBoundCIDRs,
+		TokenNoDefaultPolicy: r.TokenNoDefaultPolicy,
+		TokenNumUses:         r.TokenNumUses,
+		TokenType:            r.TokenType,
+	}
+}
+
+//TokenRoleWrite creates or updates the token role with the given name. Every
+// member of the TokenRole is sent, so a role read with TokenRoleRead can be
+// modified and written back.
+func (v *Client) TokenRoleWrite(name string, role TokenRole) error {
+	return v.TokenRoleWriteContext(context.Background(), name, role)
+}
+
+//TokenRoleWriteContext is TokenRoleWrite with a context governing the request.
+func (v *Client) TokenRoleWriteContext(ctx context.Context, name string, role TokenRole) error {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return fmt.Errorf("no role given")
+	}
+
+	return v.doRequest(ctx, "POST", fmt.Sprintf("/auth/token/roles/%s", name), role.toAPI(), nil)
+}
+
+//TokenRoleRead returns the configuration of the token role with the given
+// name. If it does not exist, an *ErrNotFound is returned.
+func (v *Client) TokenRoleRead(name string) (*TokenRole, error) {
+	return v.TokenRoleReadContext(context.Background(), name)
+}
+
+//TokenRoleReadContext is TokenRoleRead with a context governing the request.
+func (v *Client) TokenRoleReadContext(ctx context.Context, name string) (*TokenRole, error) {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return nil, fmt.Errorf("no role given")
+	}
+
+	raw := tokenRoleAPI{}
+	err := v.doRequest(ctx, "GET", fmt.Sprintf("/auth/token/roles/%s", name), nil, &vaultResponse{Data: &raw})
+	if err != nil {
+		return nil, err
+	}
+
+	return raw.Parse(), nil
+}
+
+//TokenRoleList returns the names of all of the token roles.
+func (v *Client) TokenRoleList() ([]string, error) {
+	return v.TokenRoleListContext(context.Background())
+}
+
+//TokenRoleListContext is TokenRoleList with a context governing the request.
+func (v *Client) TokenRoleListContext(ctx context.Context) ([]string, error) {
+	return v.ListContext(ctx, "/auth/token/roles")
+}
+
+//TokenRoleDelete deletes the token role with the given name. Tokens already
+// created against it are not revoked.
+func (v *Client) TokenRoleDelete(name string) error {
+	return v.TokenRoleDeleteContext(context.Background(), name)
+}
+
+//TokenRoleDeleteContext is TokenRoleDelete with a context governing the
+// request.
+func (v *Client) TokenRoleDeleteContext(ctx context.Context, name string) error {
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return fmt.Errorf("no role given")
+	}
+
+	return v.doRequest(ctx, "DELETE", fmt.Sprintf("/auth/token/roles/%s", name), nil, nil)
+}
@@ -0,0 +1,227 @@
+package vaultkv_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Token", func() {
+	var created *vaultkv.AuthOutput
+	var opts vaultkv.TokenCreateOptions
+
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		opts = vaultkv.TokenCreateOptions{
+			Policies: []string{"default"},
+			Meta:     map[string]string{"job": "build-42"},
+			TTL:      time.Hour,
+		}
+	})
+
+	Describe("TokenCreate", func() {
+		JustBeforeEach(func() {
+			created, err = vault.TokenCreate(opts)
+		})
+
+		It("should create a child token with the given options", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created.ClientToken).NotTo(BeEmpty())
+			Expect(created.ClientToken).NotTo(Equal(vault.AuthToken))
+			Expect(created.Accessor).NotTo(BeEmpty())
+			Expect(created.Policies).To(ContainElement("default"))
+			Expect(created.LeaseDuration).To(Equal(time.Hour))
+			Expect(created.Metadata).To(Equal(map[string]string{"job": "build-42"}))
+		})
+
+		Describe("TokenLookup", func() {
+			var info *vaultkv.TokenInfo
+			JustBeforeEach(func() {
+				info, err = vault.TokenLookup(created.ClientToken)
+			})
+
+			It("should describe the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.ID).To(Equal(created.ClientToken))
+				Expect(info.Accessor).To(Equal(created.Accessor))
+				Expect(info.Meta).To(Equal(map[string]string{"job": "build-42"}))
+				Expect(info.Orphan).To(BeFalse())
+			})
+		})
+
+		Describe("TokenLookupAccessor", func() {
+			var info *vaultkv.TokenInfo
+			JustBeforeEach(func() {
+				info, err = vault.TokenLookupAccessor(created.Accessor)
+			})
+
+			It("should describe the token without giving its ID", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.ID).To(BeEmpty())
+				Expect(info.Accessor).To(Equal(created.Accessor))
+			})
+		})
+
+		Describe("TokenRenew", func() {
+			var renewed *vaultkv.AuthOutput
+			JustBeforeEach(func() {
+				renewed, err = vault.TokenRenew(created.ClientToken, 2*time.Hour)
+			})
+
+			It("should extend the lease of the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(renewed.LeaseDuration).To(Equal(2 * time.Hour))
+			})
+		})
+
+		Describe("TokenListAccessors", func() {
+			var accessors []string
+			JustBeforeEach(func() {
+				accessors, err = vault.TokenListAccessors()
+			})
+
+			It("should include the accessor of the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(accessors).To(ContainElement(created.Accessor))
+			})
+		})
+
+		Describe("TokenRevokeAccessor", func() {
+			JustBeforeEach(func() {
+				err = vault.TokenRevokeAccessor(created.Accessor)
+			})
+
+			It("should revoke the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				err = vault.WithToken(created.ClientToken).TokenIsValid()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("TokenRevokeSelf", func() {
+			JustBeforeEach(func() {
+				err = vault.WithToken(created.ClientToken).TokenRevokeSelf()
+			})
+
+			It("should revoke the token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.TokenLookup(created.ClientToken)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("Revoking a parent token", func() {
+			var child *vaultkv.AuthOutput
+
+			JustBeforeEach(func() {
+				child, err = vault.WithToken(created.ClientToken).TokenCreate(vaultkv.TokenCreateOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("with TokenRevoke", func() {
+				JustBeforeEach(func() {
+					err = vault.TokenRevoke(created.ClientToken)
+				})
+
+				It("should revoke its children", func() {
+					Expect(err).NotTo(HaveOccurred())
+					_, err = vault.TokenLookup(child.ClientToken)
+					Expect(err).To(HaveOccurred())
+				})
+			})
+
+			Context("with TokenRevokeOrphan", func() {
+				JustBeforeEach(func() {
+					err = vault.TokenRevokeOrphan(created.ClientToken)
+				})
+
+				It("should leave its children as orphans", func() {
+					Expect(err).NotTo(HaveOccurred())
+					info, err := vault.TokenLookup(child.ClientToken)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(info.Orphan).To(BeTrue())
+				})
+			})
+		})
+	})
+
+	Describe("TokenCreateOrphan", func() {
+		JustBeforeEach(func() {
+			created, err = vault.TokenCreateOrphan(opts)
+		})
+
+		It("should create a token with no parent", func() {
+			Expect(err).NotTo(HaveOccurred())
+			info, err := vault.TokenLookup(created.ClientToken)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Orphan).To(BeTrue())
+		})
+	})
+
+	Describe("Token roles", func() {
+		var role vaultkv.TokenRole
+
+		BeforeEach(func() {
+			role = vaultkv.TokenRole{
+				AllowedPolicies: []string{"default", "ci"},
+				Orphan:          true,
+				Renewable:       true,
+				TokenTTL:        30 * time.Minute,
+			}
+		})
+
+		JustBeforeEach(func() {
+			err = vault.TokenRoleWrite("ci", role)
+		})
+
+		It("should be possible to read the role back", func() {
+			Expect(err).NotTo(HaveOccurred())
+			read, err := vault.TokenRoleRead("ci")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(read.Name).To(Equal("ci"))
+			Expect(read.AllowedPolicies).To(ConsistOf("default", "ci"))
+			Expect(read.Orphan).To(BeTrue())
+			Expect(read.Renewable).To(BeTrue())
+			Expect(read.TokenTTL).To(Equal(30 * time.Minute))
+		})
+
+		It("should be listed", func() {
+			Expect(err).NotTo(HaveOccurred())
+			roles, err := vault.TokenRoleList()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(roles).To(ConsistOf("ci"))
+		})
+
+		Describe("TokenCreateWithRole", func() {
+			JustBeforeEach(func() {
+				Expect(err).NotTo(HaveOccurred())
+				created, err = vault.TokenCreateWithRole("ci", vaultkv.TokenCreateOptions{
+					Policies: []string{"ci"},
+				})
+			})
+
+			It("should create a token with the settings of the role", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(created.LeaseDuration).To(Equal(30 * time.Minute))
+				info, err := vault.TokenLookup(created.ClientToken)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Orphan).To(BeTrue())
+			})
+		})
+
+		Describe("TokenRoleDelete", func() {
+			JustBeforeEach(func() {
+				Expect(err).NotTo(HaveOccurred())
+				err = vault.TokenRoleDelete("ci")
+			})
+
+			It("should delete the role", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, err = vault.TokenRoleRead("ci")
+				Expect(err).To(BeAssignableToTypeOf(&vaultkv.ErrNotFound{}))
+			})
+		})
+	})
+})
@@ -0,0 +1,253 @@
+package vaultkv
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+//TraceRedaction configures which parts of requests and responses are hidden
+// from the Trace output of a Client. Names are matched case-insensitively. An
+// empty TraceRedaction redacts nothing.
+type TraceRedaction struct {
+	//Headers are the names of headers whose values are redacted
+	Headers []string
+	//Fields are the names of JSON object members whose values are redacted
+	// wherever they appear in a request or response body.
+	Fields []string
+	//ValuesOf are the names of JSON object members whose values have every
+	// string, number, and boolean within them redacted, while the object keys
+	// within them are kept. This keeps the shape of secrets visible without
+	// their contents.
+	ValuesOf []string
+	//Allow are the names of JSON object members which are never redacted, even
+	// if they are within a member named in ValuesOf.
+	Allow []string
+	//RequestValues, if true, redacts every string, number, and boolean in the
+	// body of a request, as ValuesOf does, unless the path of the request
+	// starts with one of RequestValuesExcept. This hides the secrets of KV v1
+	// writes, which are sent as the top-level members of the body.
+	RequestValues bool
+	//RequestValuesExcept are prefixes of the paths, relative to /v1/, of
+	// requests whose bodies are not redacted by RequestValues.
+	RequestValuesExcept []string
+}
+
+//DefaultTraceRedaction returns the TraceRedaction used by a Client whose
+// TraceRedaction member is nil. It redacts token headers, the members of
+// requests and responses which carry tokens, unseal keys, and credentials, the
+// values of the data member, which holds the secrets of KV reads and writes,
+// and the values of the bodies of requests to any path outside of sys/ and
+// auth/, which may be KV v1 writes.
+func DefaultTraceRedaction() *TraceRedaction {
+	return &TraceRedaction{
+		Headers: []string{
+			"X-Vault-Token",
+			"Authorization",
+			"Cookie",
+			"Set-Cookie",
+		},
+		Fields: []string{
+			"keys",
+			"keys_base64",
+			"key",
+			"root_token",
+			"encoded_token",
+			"encoded_root_token",
+			"otp",
+			"pgp_key",
+			"client_token",
+			"token",
+			"id",
+			"secret_id",
+			"password",
+			"jwt",
+			"private_key",
+			"iam_request_url",
+			"iam_request_body",
+			"iam_request_headers",
+			"pkcs7",
+			"nonce",
+			"bindpass",
+		},
+		ValuesOf: []string{"data"},
+		Allow:    []string{"metadata"},
+
+		RequestValues:       true,
+		RequestValuesExcept: []string{"sys/", "auth/"},
+	}
+}
+
+func (t *TraceRedaction) header(name string) bool {
+	return nameIn(name, t.Headers)
+}
+
+func nameIn(name string, names []string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//requestValues returns true if every value in the body of a request to the
+// given URL path is to be redacted.
+func (t *TraceRedaction) requestValues(urlPath string) bool {
+	if !t.RequestValues {
+		return false
+	}
+
+	if i := strings.Index(urlPath, "/v1/"); i >= 0 {
+		urlPath = urlPath[i+len("/v1/"):]
+	}
+
+	for _, prefix := range t.RequestValuesExcept {
+		if strings.HasPrefix(urlPath, prefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//body returns the given request or response body with redactions applied. If
+// valuesOnly is true, every value within it is redacted. If the body is not
+// JSON, it is returned unchanged.
+func (t *TraceRedaction) body(body []byte, valuesOnly bool) []byte {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body
+	}
+
+	var parsed interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if decoder.Decode(&parsed) != nil {
+		return body
+	}
+
+	ret, err := json.Marshal(t.value(parsed, valuesOnly))
+	if err != nil {
+		return body
+	}
+
+	return ret
+}
+
+//value returns v with redactions applied. If valuesOnly is true, v is within a
+// member named in ValuesOf.
+func (t *TraceRedaction) value(v interface{}, valuesOnly bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(val))
+		for k, member := range val {
+			switch {
+			case nameIn(k, t.Allow):
+				ret[k] = member
+			case nameIn(k, t.Fields) && member != nil:
+				ret[k] = redacted
+			default:
+				ret[k] = t.value(member, valuesOnly || nameIn(k, t.ValuesOf))
+			}
+		}
+		return ret
+
+	case []interface{}:
+		ret := make([]interface{}, len(val))
+		for i := range val {
+			ret[i] = t.value(val[i], valuesOnly)
+		}
+		return ret
+
+	case nil:
+		return nil
+	}
+
+	if valuesOnly {
+		return redacted
+	}
+
+	return v
+}
+
+func (v *Client) traceRedaction() *TraceRedaction {
+	if v.TraceRedaction == nil {
+		return DefaultTraceRedaction()
+	}
+
+	return v.TraceRedaction
+}
+
+//traceRequest writes the given request to Trace with redactions applied. The
+// body of the request is replaced with an identical one, as it must be read.
+func (v *Client) traceRequest(req *http.Request) {
+	body, err := readAndReplace(&req.Body)
+	if err != nil {
+		_, _ = v.Trace.Write([]byte(fmt.Sprintf("Request:\nCould not read body: %s\n", err)))
+		return
+	}
+
+	redaction := v.traceRedaction()
+	dumpReq := req.Clone(req.Context())
+	redactHeaders(dumpReq.Header, redaction)
+	if body != nil {
+		body = redaction.body(body, redaction.requestValues(req.URL.Path))
+		dumpReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dumpReq.ContentLength = int64(len(body))
+	}
+
+	dump, _ := httputil.DumpRequest(dumpReq, true)
+	_, _ = v.Trace.Write([]byte(fmt.Sprintf("Request:\n%s\n", dump)))
+}
+
+//traceResponse writes the given response to Trace with redactions applied. The
+// body of the response is replaced with an identical one, as it must be read.
+func (v *Client) traceResponse(resp *http.Response) {
+	body, err := readAndReplace(&resp.Body)
+	if err != nil {
+		_, _ = v.Trace.Write([]byte(fmt.Sprintf("Response:\nCould not read body: %s\n", err)))
+		return
+	}
+
+	redaction := v.traceRedaction()
+	dumpResp := *resp
+	dumpResp.Header = resp.Header.Clone()
+	redactHeaders(dumpResp.Header, redaction)
+	if body != nil {
+		body = redaction.body(body, false)
+		dumpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		dumpResp.ContentLength = int64(len(body))
+	}
+
+	dump, _ := httputil.DumpResponse(&dumpResp, true)
+	_, _ = v.Trace.Write([]byte(fmt.Sprintf("Response:\n%s\n", dump)))
+}
+
+func redactHeaders(header http.Header, redaction *TraceRedaction) {
+	for name := range header {
+		if redaction.header(name) {
+			header[name] = []string{redacted}
+		}
+	}
+}
+
+//readAndReplace reads the entirety of the given body, closes it, and replaces
+// it with a reader of the same content.
+func readAndReplace(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	contents, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	*body = ioutil.NopCloser(bytes.NewReader(contents))
+	return contents, err
+}
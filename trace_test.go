@@ -0,0 +1,186 @@
+package vaultkv_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trace redaction", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var trace *bytes.Buffer
+	var receivedBody []byte
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			receivedBody, _ = ioutil.ReadAll(r.Body)
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{
+				"request_id": "abcd",
+				"auth": {"client_token": "s.supersecrettoken", "policies": ["default"]},
+				"data": {
+					"data": {"username": "admin", "api_key": "hunter2"},
+					"metadata": {"version": 3}
+				}
+			}`))
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		trace = &bytes.Buffer{}
+		client = &vaultkv.Client{
+			AuthToken: "s.myclienttoken",
+			VaultURL:  serverURL,
+			Trace:     trace,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	JustBeforeEach(func() {
+		_, err = client.V2Set("secret", "foo", map[string]string{
+			"password": "correcthorsebatterystaple",
+		}, nil)
+	})
+
+	It("should hide secrets from the trace by default", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		By("redacting the token header")
+		Expect(trace.String()).To(ContainSubstring("X-Vault-Token: [redacted]"))
+		Expect(trace.String()).NotTo(ContainSubstring("s.myclienttoken"))
+
+		By("redacting sensitive fields")
+		Expect(trace.String()).NotTo(ContainSubstring("correcthorsebatterystaple"))
+		Expect(trace.String()).NotTo(ContainSubstring("s.supersecrettoken"))
+
+		By("redacting the values of data, but not its keys")
+		Expect(trace.String()).NotTo(ContainSubstring("hunter2"))
+		Expect(trace.String()).To(ContainSubstring("api_key"))
+
+		By("leaving the rest of the response alone")
+		Expect(trace.String()).To(ContainSubstring(`"request_id":"abcd"`))
+		Expect(trace.String()).To(ContainSubstring(`"version":3`))
+	})
+
+	It("should send the request body unchanged", func() {
+		Expect(string(receivedBody)).To(ContainSubstring("correcthorsebatterystaple"))
+	})
+
+	When("a KV v1 secret is written", func() {
+		JustBeforeEach(func() {
+			trace.Reset()
+			err = client.Set("secret/foo", map[string]string{"apikey": "sk-live-12345"})
+		})
+
+		It("should redact the values of the body, but not its keys", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).NotTo(ContainSubstring("sk-live-12345"))
+			Expect(trace.String()).To(ContainSubstring(`"apikey":"[redacted]"`))
+			Expect(string(receivedBody)).To(ContainSubstring("sk-live-12345"))
+		})
+	})
+
+	When("a request is made to a sys/ path", func() {
+		JustBeforeEach(func() {
+			trace.Reset()
+			err = client.TuneSecretsMount("secret", vaultkv.TuneMountOptions{Description: "my secrets"})
+		})
+
+		It("should leave the body alone", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).To(ContainSubstring(`"description":"my secrets"`))
+		})
+	})
+
+	When("logging in with AWS", func() {
+		JustBeforeEach(func() {
+			trace.Reset()
+			_, err = client.AuthAWSIAM("web", vaultkv.AWSIAMLoginOptions{
+				Credentials: &vaultkv.AWSCredentials{
+					AccessKeyID:     "AKIDEXAMPLE",
+					SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+					SessionToken:    "FwoGZXIvYXdzEXAMPLE",
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.AuthAWSEC2("web", "MIAGCSqGSIb3DQEHAqCAMIACAQEx", "my-nonce")
+		})
+
+		It("should redact the signed request and the identity document", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).To(ContainSubstring(`"iam_request_headers":"[redacted]"`))
+			Expect(trace.String()).To(ContainSubstring(`"iam_request_body":"[redacted]"`))
+			Expect(trace.String()).To(ContainSubstring(`"iam_request_url":"[redacted]"`))
+			Expect(trace.String()).NotTo(ContainSubstring("MIAGCSqGSIb3DQEHAqCAMIACAQEx"))
+			Expect(trace.String()).NotTo(ContainSubstring("my-nonce"))
+		})
+	})
+
+	When("configuring LDAP", func() {
+		JustBeforeEach(func() {
+			trace.Reset()
+			err = client.LDAPConfigWrite("ldap", vaultkv.LDAPConfig{
+				URL:      "ldaps://ldap.example.com",
+				BindDN:   "cn=vault,dc=example,dc=com",
+				BindPass: "bind-hunter2",
+			})
+		})
+
+		It("should redact the bind password", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).To(ContainSubstring(`"bindpass":"[redacted]"`))
+			Expect(trace.String()).To(ContainSubstring("ldaps://ldap.example.com"))
+		})
+	})
+
+	When("the response is decoded", func() {
+		It("should be decoded unredacted", func() {
+			output := map[string]string{}
+			_, err = client.V2Get("secret", "foo", &output, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).To(HaveKeyWithValue("api_key", "hunter2"))
+		})
+	})
+
+	When("a custom TraceRedaction is given", func() {
+		BeforeEach(func() {
+			client.TraceRedaction = &vaultkv.TraceRedaction{
+				Headers: []string{"x-vault-token"},
+				Fields:  []string{"api_key"},
+				Allow:   []string{"password"},
+			}
+		})
+
+		It("should redact only what it names", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).NotTo(ContainSubstring("s.myclienttoken"))
+			Expect(trace.String()).NotTo(ContainSubstring("hunter2"))
+			Expect(trace.String()).To(ContainSubstring("correcthorsebatterystaple"))
+			Expect(trace.String()).To(ContainSubstring("s.supersecrettoken"))
+		})
+	})
+
+	When("an empty TraceRedaction is given", func() {
+		BeforeEach(func() {
+			client.TraceRedaction = &vaultkv.TraceRedaction{}
+		})
+
+		It("should redact nothing", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trace.String()).To(ContainSubstring("s.myclienttoken"))
+			Expect(trace.String()).To(ContainSubstring("hunter2"))
+		})
+	})
+})
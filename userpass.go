@@ -0,0 +1,133 @@
+package vaultkv
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+//userpassUserPath returns the path of the given user of the userpass auth
+// method at the given mount, followed by the given suffix.
+func userpassUserPath(mount, username, suffix string) (string, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return "", fmt.Errorf("no mountpoint given")
+	}
+
+	username = strings.Trim(username, "/")
+	if username == "" {
+		return "", fmt.Errorf("no username given")
+	}
+
+	return fmt.Sprintf("/auth/%s/users/%s%s", mount, username, suffix), nil
+}
+
+//UserpassUserCreate creates the user with the given username and password in
+// the userpass auth method at the given mount, such that logging in as it with
+// AuthUserpassMount gives a token with the given policies. If the user already
+// exists, its password and policies are replaced. Given mountpoint is relative
+// to /v1/auth.
+func (v *Client) UserpassUserCreate(mount, username, password string, policies []string) error {
+	return v.UserpassUserCreateContext(context.Background(), mount, username, password, policies)
+}
+
+//UserpassUserCreateContext is UserpassUserCreate with a context governing the
+// request.
+func (v *Client) UserpassUserCreateContext(ctx context.Context, mount, username, password string, policies []string) error {
+	path, err := userpassUserPath(mount, username, "")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		Password      string   `json:"password"`
+		TokenPolicies []string `json:"token_policies"`
+	}{
+		Password:      password,
+		TokenPolicies: policies,
+	}, nil)
+}
+
+//UserpassUserUpdatePassword changes the password of the user with the given
+// username in the userpass auth method at the given mount. Tokens already
+// issued to the user are not revoked.
+func (v *Client) UserpassUserUpdatePassword(mount, username, password string) error {
+	return v.UserpassUserUpdatePasswordContext(context.Background(), mount, username, password)
+}
+
+//UserpassUserUpdatePasswordContext is UserpassUserUpdatePassword with a
+// context governing the request.
+func (v *Client) UserpassUserUpdatePasswordContext(ctx context.Context, mount, username, password string) error {
+	path, err := userpassUserPath(mount, username, "/password")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		Password string `json:"password"`
+	}{
+		Password: password,
+	}, nil)
+}
+
+//UserpassUserUpdatePolicies replaces the policies given to the tokens of the
+// user with the given username in the userpass auth method at the given mount.
+func (v *Client) UserpassUserUpdatePolicies(mount, username string, policies []string) error {
+	return v.UserpassUserUpdatePoliciesContext(context.Background(), mount, username, policies)
+}
+
+//UserpassUserUpdatePoliciesContext is UserpassUserUpdatePolicies with a
+// context governing the request.
+func (v *Client) UserpassUserUpdatePoliciesContext(ctx context.Context, mount, username string, policies []string) error {
+	path, err := userpassUserPath(mount, username, "/policies")
+	if err != nil {
+		return err
+	}
+
+	//An empty list must be sent as such, rather than as null, to remove all of
+	// the policies
+	if policies == nil {
+		policies = []string{}
+	}
+
+	return v.doRequest(ctx, "POST", path, struct {
+		TokenPolicies []string `json:"token_policies"`
+	}{
+		TokenPolicies: policies,
+	}, nil)
+}
+
+//UserpassUserDelete deletes the user with the given username in the userpass
+// auth method at the given mount. Tokens already issued to the user are not
+// revoked.
+func (v *Client) UserpassUserDelete(mount, username string) error {
+	return v.UserpassUserDeleteContext(context.Background(), mount, username)
+}
+
+//UserpassUserDeleteContext is UserpassUserDelete with a context governing the
+// request.
+func (v *Client) UserpassUserDeleteContext(ctx context.Context, mount, username string) error {
+	path, err := userpassUserPath(mount, username, "")
+	if err != nil {
+		return err
+	}
+
+	return v.doRequest(ctx, "DELETE", path, nil, nil)
+}
+
+//UserpassUserList returns the usernames of all of the users in the userpass
+// auth method at the given mount.
+func (v *Client) UserpassUserList(mount string) ([]string, error) {
+	return v.UserpassUserListContext(context.Background(), mount)
+}
+
+//UserpassUserListContext is UserpassUserList with a context governing the
+// request.
+func (v *Client) UserpassUserListContext(ctx context.Context, mount string) ([]string, error) {
+	mount = strings.Trim(mount, "/")
+	if mount == "" {
+		return nil, fmt.Errorf("no mountpoint given")
+	}
+
+	return v.ListContext(ctx, fmt.Sprintf("/auth/%s/users", mount))
+}
@@ -0,0 +1,87 @@
+package vaultkv_test
+
+import (
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Userpass", func() {
+	var login *vaultkv.AuthOutput
+
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		err = vault.EnableAuthMount("userpass", vaultkv.Mount{Type: "userpass"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	JustBeforeEach(func() {
+		err = vault.UserpassUserCreate("userpass", "breakglass", "hunter2", []string{"default"})
+	})
+
+	It("should not err", func() { Expect(err).NotTo(HaveOccurred()) })
+
+	Describe("Logging in as the user", func() {
+		JustBeforeEach(func() {
+			login, err = vault.WithToken("").AuthUserpass("breakglass", "hunter2")
+		})
+
+		It("should give a token with the policies of the user", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(login.Policies).To(ContainElement("default"))
+			Expect(login.Metadata).To(Equal(vaultkv.AuthUserpassMetadata{Username: "breakglass"}))
+		})
+	})
+
+	Describe("UserpassUserList", func() {
+		var users []string
+		JustBeforeEach(func() {
+			users, err = vault.UserpassUserList("userpass")
+		})
+
+		It("should list the user", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users).To(Equal([]string{"breakglass"}))
+		})
+	})
+
+	Describe("UserpassUserUpdatePassword", func() {
+		JustBeforeEach(func() {
+			err = vault.UserpassUserUpdatePassword("userpass", "breakglass", "correcthorse")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should only allow logging in with the new password", func() {
+			_, err = vault.WithToken("").AuthUserpass("breakglass", "hunter2")
+			Expect(err).To(HaveOccurred())
+
+			_, err = vault.WithToken("").AuthUserpass("breakglass", "correcthorse")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("UserpassUserUpdatePolicies", func() {
+		JustBeforeEach(func() {
+			err = vault.UserpassUserUpdatePolicies("userpass", "breakglass", []string{"admin"})
+			Expect(err).NotTo(HaveOccurred())
+			login, err = vault.WithToken("").AuthUserpass("breakglass", "hunter2")
+		})
+
+		It("should give the new policies on login", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(login.Policies).To(ContainElement("admin"))
+		})
+	})
+
+	Describe("UserpassUserDelete", func() {
+		JustBeforeEach(func() {
+			err = vault.UserpassUserDelete("userpass", "breakglass")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = vault.WithToken("").AuthUserpass("breakglass", "hunter2")
+		})
+
+		It("should stop the user from logging in", func() {
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
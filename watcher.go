@@ -0,0 +1,343 @@
+package vaultkv
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//TokenEventType identifies what happened in a TokenEvent
+type TokenEventType int
+
+const (
+	//TokenRenewed events are sent when the token has been renewed
+	TokenRenewed TokenEventType = iota
+	//TokenLoggedIn events are sent when a new token has been obtained from the
+	// Login function of the TokenWatcher
+	TokenLoggedIn
+	//TokenRenewFailed events are sent when the token could not be renewed or
+	// looked up. The renewal is retried, or a new token obtained with Login if
+	// the token is no longer valid.
+	TokenRenewFailed
+	//TokenLoginFailed events are sent when the Login function returned an
+	// error. The login is retried.
+	TokenLoginFailed
+	//TokenExpiring events are sent when the token cannot be renewed any further
+	// and there is no Login function to get another one with. The token will
+	// expire at the Expires time of the event, and the TokenWatcher does nothing
+	// more.
+	TokenExpiring
+)
+
+func (t TokenEventType) String() string {
+	switch t {
+	case TokenRenewed:
+		return "renewed"
+	case TokenLoggedIn:
+		return "logged in"
+	case TokenRenewFailed:
+		return "renew failed"
+	case TokenLoginFailed:
+		return "login failed"
+	case TokenExpiring:
+		return "expiring"
+	}
+
+	return "unknown"
+}
+
+//TokenEvent describes something that a TokenWatcher did.
+type TokenEvent struct {
+	Type TokenEventType
+	//Expires is when the current token expires, as best known after the
+	// event. It is the zero time if the token does not expire.
+	Expires time.Time
+	//Err is the error that caused a TokenRenewFailed or TokenLoginFailed event
+	Err error
+}
+
+//TokenWatcher keeps the token of a Client alive in the background. It renews
+// the token once RenewFraction of its TTL has passed, and when the token can no
+// longer be renewed, such as once it reaches its explicit max TTL, it gets a
+// new one by calling Login. Create one with NewTokenWatcher, and set any
+// members before calling Start.
+type TokenWatcher struct {
+	//Client is the Client whose token is kept alive
+	Client *Client
+	//RenewFraction is the fraction of the TTL of the token, as of its last
+	// renewal, after which it is renewed again. If zero, two thirds is used.
+	RenewFraction float64
+	//Increment is the TTL asked for with each renewal. If zero, the token is
+	// renewed with its default TTL.
+	Increment time.Duration
+	//Login, if non-nil, is called to get a new token when the current one
+	// cannot be renewed, or if the Client has no token when the TokenWatcher is
+	// started. It will typically call one of the AuthX functions of the given
+	// Client, which sets its token. If it returns a non-nil AuthOutput, the
+	// token of the Client is set to the one in it.
+	Login func(ctx context.Context, v *Client) (*AuthOutput, error)
+	//RetryInterval is the time to wait after a failed renewal or login before
+	// trying again. If zero, ten seconds is used.
+	RetryInterval time.Duration
+
+	events chan TokenEvent
+	lock   sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+//expiryTolerance is how close two expiry times must be to be considered the
+// same, to allow for the rounding of TTLs to seconds and for clock skew
+const expiryTolerance = time.Second
+
+//tokenEventBuffer is the number of events that are kept for a slow reader of
+// the events channel before further events are dropped
+const tokenEventBuffer = 16
+
+//NewTokenWatcher returns a TokenWatcher for the given Client, which obtains
+// new tokens with the given login function if it is non-nil.
+func NewTokenWatcher(v *Client, login func(ctx context.Context, v *Client) (*AuthOutput, error)) *TokenWatcher {
+	return &TokenWatcher{
+		Client: v,
+		Login:  login,
+		events: make(chan TokenEvent, tokenEventBuffer),
+	}
+}
+
+//Events returns the channel on which the TokenWatcher reports what it does.
+// Reading from it is optional. Events are dropped, rather than holding up
+// renewal, if too many go unread.
+func (w *TokenWatcher) Events() <-chan TokenEvent {
+	return w.events
+}
+
+//Start begins watching the token in a new goroutine, which runs until the
+// given context is done or Stop is called. Calling Start on a TokenWatcher that
+// is already running does nothing.
+func (w *TokenWatcher) Start(ctx context.Context) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.done != nil {
+		return
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go w.run(ctx, w.done)
+}
+
+//Stop stops the TokenWatcher and waits for it to finish. The token is not
+// revoked.
+func (w *TokenWatcher) Stop() {
+	w.lock.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.lock.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+//tokenState is what the TokenWatcher knows about the current token
+type tokenState struct {
+	//expires is zero if the token does not expire
+	expires time.Time
+	//renewAt is when the token should next be renewed or replaced
+	renewAt time.Time
+	//ttl is the TTL that the token was last given
+	ttl time.Duration
+	//renewable is false once renewing the token would not extend its life
+	renewable bool
+	//maxExpires is the time the token expires at regardless of renewal, or zero
+	// if it is not known to have one.
+	maxExpires time.Time
+}
+
+func (w *TokenWatcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	var state tokenState
+	var err error
+	if w.Client.authToken() == "" && w.Login != nil {
+		state, err = w.login(ctx)
+	} else {
+		state, err = w.refresh(ctx)
+	}
+
+	for {
+		if err != nil {
+			if !sleepContext(ctx, w.retryInterval()) {
+				return
+			}
+
+			state, err = w.refresh(ctx)
+			continue
+		}
+
+		if state.expires.IsZero() {
+			//Nothing to do for tokens which never expire
+			<-ctx.Done()
+			return
+		}
+
+		if !state.renewable && w.Login == nil {
+			w.send(TokenEvent{Type: TokenExpiring, Expires: state.expires})
+			<-ctx.Done()
+			return
+		}
+
+		if !sleepContext(ctx, time.Until(state.renewAt)) {
+			return
+		}
+
+		if !state.renewable {
+			state, err = w.login(ctx)
+			continue
+		}
+
+		var renewed tokenState
+		renewed, err = w.renew(ctx, state)
+		if err != nil {
+			w.send(TokenEvent{Type: TokenRenewFailed, Expires: state.expires, Err: err})
+			if IsForbidden(err) && w.Login != nil {
+				state, err = w.login(ctx)
+			}
+			continue
+		}
+
+		state = renewed
+		w.send(TokenEvent{Type: TokenRenewed, Expires: state.expires})
+	}
+}
+
+//refresh looks up the state of the current token, and gets a new token with
+// Login if the current one is no longer valid.
+func (w *TokenWatcher) refresh(ctx context.Context) (tokenState, error) {
+	state, err := w.lookup(ctx)
+	if err == nil {
+		return state, nil
+	}
+
+	w.send(TokenEvent{Type: TokenRenewFailed, Err: err})
+	if IsForbidden(err) && w.Login != nil {
+		return w.login(ctx)
+	}
+
+	return state, err
+}
+
+//lookup gets the state of the current token from Vault
+func (w *TokenWatcher) lookup(ctx context.Context) (tokenState, error) {
+	info, err := w.Client.TokenInfoSelfContext(ctx)
+	if err != nil {
+		return tokenState{}, err
+	}
+
+	ret := tokenState{renewable: info.Renewable}
+	if info.ExplicitMaxTTL > 0 {
+		ret.maxExpires = info.CreationTime.Add(info.ExplicitMaxTTL)
+	}
+
+	w.schedule(&ret, info.TTL)
+	return ret, nil
+}
+
+//renew renews the current token, and returns its new state
+func (w *TokenWatcher) renew(ctx context.Context, old tokenState) (tokenState, error) {
+	out, err := w.Client.TokenRenewSelfIncrementContext(ctx, w.Increment)
+	if err != nil {
+		return old, err
+	}
+
+	ret := tokenState{
+		renewable:  out.Renewable,
+		maxExpires: old.maxExpires,
+	}
+	w.schedule(&ret, out.LeaseDuration)
+
+	//A renewal that grants less time than the last one, or none at all, means
+	// that the token has hit a max TTL, whether or not it is one that we knew
+	// about.
+	if out.LeaseDuration <= 0 {
+		ret.expires, ret.renewAt, ret.renewable = old.expires, time.Now(), false
+	} else if out.LeaseDuration < old.ttl-expiryTolerance {
+		ret.renewable = false
+	}
+
+	return ret, nil
+}
+
+//login gets a new token with Login and looks up its state. Failures are
+// reported in events.
+func (w *TokenWatcher) login(ctx context.Context) (tokenState, error) {
+	out, err := w.Login(ctx, w.Client)
+	if err != nil {
+		w.send(TokenEvent{Type: TokenLoginFailed, Err: err})
+		return tokenState{}, err
+	}
+
+	if out != nil && out.ClientToken != "" {
+		w.Client.SetAuthToken(out.ClientToken)
+	}
+
+	state, err := w.lookup(ctx)
+	if err != nil {
+		w.send(TokenEvent{Type: TokenRenewFailed, Err: err})
+		return state, err
+	}
+
+	w.send(TokenEvent{Type: TokenLoggedIn, Expires: state.expires})
+	return state, nil
+}
+
+//schedule sets when a token with the given TTL from now expires, and when it
+// should next be renewed or replaced.
+func (w *TokenWatcher) schedule(state *tokenState, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	now := time.Now()
+	state.ttl = ttl
+	state.expires = now.Add(ttl)
+	if !state.maxExpires.IsZero() && state.maxExpires.Before(state.expires.Add(expiryTolerance)) {
+		state.renewable = false
+	}
+
+	fraction := w.RenewFraction
+	if fraction <= 0 || fraction >= 1 {
+		fraction = 2.0 / 3.0
+	}
+	state.renewAt = now.Add(time.Duration(fraction * float64(ttl)))
+}
+
+func (w *TokenWatcher) retryInterval() time.Duration {
+	if w.RetryInterval <= 0 {
+		return 10 * time.Second
+	}
+
+	return w.RetryInterval
+}
+
+func (w *TokenWatcher) send(event TokenEvent) {
+	select {
+	case w.events <- event:
+	default:
+	}
+}
+
+//sleepContext waits for the given duration, and returns false if the context
+// ended first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
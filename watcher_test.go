@@ -0,0 +1,186 @@
+package vaultkv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenWatcher", func() {
+	var server *httptest.Server
+	var client *vaultkv.Client
+	var watcher *vaultkv.TokenWatcher
+	var login func(ctx context.Context, v *vaultkv.Client) (*vaultkv.AuthOutput, error)
+
+	//The state of the fake Vault
+	var lock sync.Mutex
+	var validToken string
+	var renewable bool
+	var renewals, logins int
+
+	BeforeEach(func() {
+		validToken = "s.first"
+		renewable = true
+		renewals, logins = 0, 0
+		login = nil
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+
+			if r.Header.Get("X-Vault-Token") != validToken {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+				return
+			}
+
+			switch r.URL.Path {
+			case "/v1/auth/token/lookup-self":
+				_, _ = fmt.Fprintf(w, `{"data":{"ttl":1,"renewable":%t,"creation_time":%d}}`, renewable, time.Now().Unix())
+			case "/v1/auth/token/renew-self":
+				renewals++
+				_, _ = fmt.Fprintf(w, `{"auth":{"client_token":%q,"lease_duration":1,"renewable":%t}}`, validToken, renewable)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+			}
+		}))
+
+		serverURL, err := url.Parse(server.URL)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = &vaultkv.Client{
+			AuthToken: "s.first",
+			VaultURL:  serverURL,
+			Trace:     GinkgoWriter,
+		}
+	})
+
+	JustBeforeEach(func() {
+		watcher = vaultkv.NewTokenWatcher(client, login)
+		watcher.RetryInterval = 50 * time.Millisecond
+		watcher.Start(context.Background())
+	})
+
+	AfterEach(func() {
+		watcher.Stop()
+		server.Close()
+	})
+
+	//loginAs returns a login function which makes the fake Vault accept the
+	// given token, and sets it on the client as the AuthX functions do
+	loginAs := func(token string) func(ctx context.Context, v *vaultkv.Client) (*vaultkv.AuthOutput, error) {
+		return func(ctx context.Context, v *vaultkv.Client) (*vaultkv.AuthOutput, error) {
+			lock.Lock()
+			defer lock.Unlock()
+			logins++
+			validToken = token
+			renewable = true
+			return &vaultkv.AuthOutput{ClientToken: token, LeaseDuration: time.Second, Renewable: true}, nil
+		}
+	}
+
+	nextEvent := func() vaultkv.TokenEvent {
+		var event vaultkv.TokenEvent
+		Eventually(watcher.Events(), 3*time.Second).Should(Receive(&event))
+		return event
+	}
+
+	When("the token is renewable", func() {
+		It("should renew it before it expires", func() {
+			event := nextEvent()
+			Expect(event.Type).To(Equal(vaultkv.TokenRenewed))
+			Expect(event.Expires).To(BeTemporally("~", time.Now().Add(time.Second), 500*time.Millisecond))
+
+			Expect(nextEvent().Type).To(Equal(vaultkv.TokenRenewed))
+			lock.Lock()
+			Expect(renewals).To(Equal(2))
+			lock.Unlock()
+		})
+	})
+
+	When("the token cannot be renewed", func() {
+		BeforeEach(func() {
+			renewable = false
+		})
+
+		Context("and there is a login function", func() {
+			BeforeEach(func() {
+				login = loginAs("s.second")
+			})
+
+			It("should log in again before the token expires", func() {
+				Expect(nextEvent().Type).To(Equal(vaultkv.TokenLoggedIn))
+				Expect(client.AuthToken).To(Equal("s.second"))
+				lock.Lock()
+				Expect(renewals).To(Equal(0))
+				lock.Unlock()
+
+				By("renewing the new token")
+				Expect(nextEvent().Type).To(Equal(vaultkv.TokenRenewed))
+			})
+		})
+
+		Context("and there is no login function", func() {
+			It("should report that the token is expiring", func() {
+				event := nextEvent()
+				Expect(event.Type).To(Equal(vaultkv.TokenExpiring))
+				Expect(event.Expires).To(BeTemporally("~", time.Now().Add(time.Second), 500*time.Millisecond))
+			})
+		})
+	})
+
+	When("the token has been revoked", func() {
+		BeforeEach(func() {
+			validToken = "s.other"
+			login = loginAs("s.second")
+		})
+
+		It("should log in again", func() {
+			event := nextEvent()
+			Expect(event.Type).To(Equal(vaultkv.TokenRenewFailed))
+			Expect(vaultkv.IsForbidden(event.Err)).To(BeTrue())
+			Expect(nextEvent().Type).To(Equal(vaultkv.TokenLoggedIn))
+			Expect(client.AuthToken).To(Equal("s.second"))
+		})
+	})
+
+	When("the client has no token", func() {
+		BeforeEach(func() {
+			client.AuthToken = ""
+			login = loginAs("s.second")
+		})
+
+		It("should log in first", func() {
+			Expect(nextEvent().Type).To(Equal(vaultkv.TokenLoggedIn))
+			lock.Lock()
+			Expect(logins).To(Equal(1))
+			lock.Unlock()
+		})
+	})
+
+	When("the login fails", func() {
+		BeforeEach(func() {
+			client.AuthToken = ""
+			login = func(ctx context.Context, v *vaultkv.Client) (*vaultkv.AuthOutput, error) {
+				return nil, fmt.Errorf("bad credentials")
+			}
+		})
+
+		It("should report it and try again", func() {
+			event := nextEvent()
+			Expect(event.Type).To(Equal(vaultkv.TokenLoginFailed))
+			Expect(event.Err).To(MatchError("bad credentials"))
+			Expect(nextEvent().Type).To(Equal(vaultkv.TokenRenewFailed))
+		})
+	})
+})
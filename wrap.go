@@ -0,0 +1,293 @@
+package vaultkv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//WrapInfo describes a response-wrapping token, which can be exchanged exactly
+// once for the response that it wraps.
+type WrapInfo struct {
+	Token           string
+	Accessor        string
+	TTL             time.Duration
+	CreationTime    time.Time
+	CreationPath    string
+	WrappedAccessor string
+}
+
+type wrapInfoAPI struct {
+	Token           string `json:"token"`
+	Accessor        string `json:"accessor"`
+	TTL             int64  `json:"ttl"`
+	CreationTime    string `json:"creation_time"`
+	CreationPath    string `json:"creation_path"`
+	WrappedAccessor string `json:"wrapped_accessor"`
+}
+
+func (w wrapInfoAPI) Parse() *WrapInfo {
+	ret := &WrapInfo{
+		Token:           w.Token,
+		Accessor:        w.Accessor,
+		TTL:             time.Duration(w.TTL) * time.Second,
+		CreationPath:    w.CreationPath,
+		WrappedAccessor: w.WrappedAccessor,
+	}
+
+	ret.CreationTime, _ = time.Parse(time.RFC3339Nano, w.CreationTime)
+	return ret
+}
+
+//errResponseWrapped is returned from doRequest to stop a call from processing
+// a response that contains a wrapping token instead of the data it expected.
+// RequestWrapped turns it back into a success.
+var errResponseWrapped = errors.New("response was wrapped")
+
+type wrapContextKey struct{}
+
+type wrapState struct {
+	ttl  time.Duration
+	lock sync.Mutex
+	info *WrapInfo
+}
+
+//wrapStateFrom returns the wrap state of the given context, or nil if requests
+// made with it are not to be wrapped. Once a response has been wrapped, no
+// further requests are.
+func wrapStateFrom(ctx context.Context) *wrapState {
+	state, _ := ctx.Value(wrapContextKey{}).(*wrapState)
+	if state == nil || state.wrapped() != nil {
+		return nil
+	}
+
+	return state
+}
+
+//wrapped returns the wrap info of the wrapped response, if there has been one.
+func (w *wrapState) wrapped() *WrapInfo {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.info
+}
+
+//withoutWrapping returns a context under which requests are not wrapped, for
+// requests made in support of the request that the caller asked to wrap.
+func withoutWrapping(ctx context.Context) context.Context {
+	if wrapStateFrom(ctx) == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, wrapContextKey{}, (*wrapState)(nil))
+}
+
+//record stores the wrap info from the given response body, if any, and
+// returns whether there was any.
+func (w *wrapState) record(body []byte) (bool, error) {
+	envelope := struct {
+		WrapInfo *wrapInfoAPI `json:"wrap_info"`
+	}{}
+
+	err := json.Unmarshal(body, &envelope)
+	if err != nil || envelope.WrapInfo == nil {
+		return false, err
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.info != nil {
+		//Another request under the same context was wrapped first
+		return false, nil
+	}
+
+	w.info = envelope.WrapInfo.Parse()
+	return true, nil
+}
+
+//RequestWrapped calls the given function, asking Vault to wrap the response to
+// the request it makes with the given context, and returns the resulting wrap
+// info. Any call that takes a context can be wrapped this way, such as
+// KV.GetContext or AuthApproleMountContext. The output values of the call
+// are left unpopulated, and any side effects of the call that would use its
+// output, such as setting the client's AuthToken after a login, do not occur.
+// Only the first response to be wrapped is returned; requests made under the
+// context after it are not wrapped. Helper requests that a call makes before
+// the request it is for, such as those KV makes to find the version of a
+// mount, or a login with the AuthMethod of the Client, are not wrapped either.
+// If the endpoint does not return a wrapped response, an error is returned.
+func (v *Client) RequestWrapped(ctx context.Context, ttl time.Duration, call func(ctx context.Context) error) (*WrapInfo, error) {
+	if ttl < time.Second {
+		return nil, fmt.Errorf("wrap TTL must be at least one second")
+	}
+
+	state := &wrapState{ttl: ttl}
+	err := call(context.WithValue(ctx, wrapContextKey{}, state))
+	if info := state.wrapped(); info != nil && (err == nil || errors.Is(err, errResponseWrapped)) {
+		return info, nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("Vault did not return a wrapped response")
+	}
+
+	return nil, err
+}
+
+//Unwrap exchanges the given wrapping token for the response that it wraps, and
+// decodes the whole response into output using the semantics of
+// encoding/json.Unmarshal. The wrapping token is used to authenticate this
+// request in place of the client's AuthToken. A wrapping token can only be
+// unwrapped once.
+func (v *Client) Unwrap(token string, output interface{}) error {
+	return v.UnwrapContext(context.Background(), token, output)
+}
+
+//UnwrapContext is Unwrap with a context governing the request.
+func (v *Client) UnwrapContext(ctx context.Context, token string, output interface{}) error {
+	//The unwrapper has no AuthMethod, so that a wrapping token which is refused
+	// does not cause a login, and a retry with the token of this Client
+	return v.WithToken(token).doRequest(withoutWrapping(ctx), "PUT", "/sys/wrapping/unwrap", nil, output)
+}
+
+//UnwrapGet unwraps a wrapped response from Client.Get or from KV.Get against a
+// KV v1 backend, and decodes the secret into output with the same semantics as
+// Client.Get.
+func (v *Client) UnwrapGet(token string, output interface{}) error {
+	return v.UnwrapGetContext(context.Background(), token, output)
+}
+
+//UnwrapGetContext is UnwrapGet with a context governing the request.
+func (v *Client) UnwrapGetContext(ctx context.Context, token string, output interface{}) error {
+	return v.UnwrapContext(ctx, token, &vaultResponse{Data: output})
+}
+
+//UnwrapV2Get unwraps a wrapped response from V2Get or from KV.Get against a KV
+// v2 backend, and decodes the secret into output with the same semantics as
+// V2Get.
+func (v *Client) UnwrapV2Get(token string, output interface{}) (meta V2Version, err error) {
+	return v.UnwrapV2GetContext(context.Background(), token, output)
+}
+
+//UnwrapV2GetContext is UnwrapV2Get with a context governing the request.
+func (v *Client) UnwrapV2GetContext(ctx context.Context, token string, output interface{}) (meta V2Version, err error) {
+	type outputData struct {
+		Metadata v2VersionAPI `json:"metadata"`
+		Data     interface{}  `json:"data"`
+	}
+
+	unmarshalInto := &struct {
+		Data outputData `json:"data"`
+	}{
+		Data: outputData{Data: output},
+	}
+
+	err = v.UnwrapContext(ctx, token, unmarshalInto)
+	if err != nil {
+		return
+	}
+
+	meta = unmarshalInto.Data.Metadata.Parse()
+	return
+}
+
+//UnwrapAuth unwraps a wrapped response from a login or token creation, and
+// returns it as an AuthOutput. As with the AuthX functions, this client's
+// AuthToken is set to the unwrapped token.
+func (v *Client) UnwrapAuth(token string) (*AuthOutput, error) {
+	return v.UnwrapAuthContext(context.Background(), token)
+}
+
+//UnwrapAuthContext is UnwrapAuth with a context governing the request.
+func (v *Client) UnwrapAuthContext(ctx context.Context, token string) (*AuthOutput, error) {
+	raw := &authOutputRaw{}
+	err := v.UnwrapContext(ctx, token, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw.Auth.ClientToken == "" {
+		return nil, fmt.Errorf("wrapped response did not contain a token")
+	}
+
+	ret, err := raw.toFinal(nil)
+	if err != nil {
+		return nil, err
+	}
+	v.SetAuthToken(ret.ClientToken)
+	return ret, nil
+}
+
+//WrapLookup returns information about the given wrapping token without
+// unwrapping it. The Token and Accessor members of the returned WrapInfo are
+// not populated.
+func (v *Client) WrapLookup(token string) (*WrapInfo, error) {
+	return v.WrapLookupContext(context.Background(), token)
+}
+
+//WrapLookupContext is WrapLookup with a context governing the request.
+func (v *Client) WrapLookupContext(ctx context.Context, token string) (*WrapInfo, error) {
+	output := struct {
+		Data struct {
+			CreationPath string `json:"creation_path"`
+			CreationTime string `json:"creation_time"`
+			CreationTTL  int64  `json:"creation_ttl"`
+		} `json:"data"`
+	}{}
+
+	err := v.doRequest(withoutWrapping(ctx), "PUT", "/sys/wrapping/lookup", struct {
+		Token string `json:"token"`
+	}{Token: token}, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapInfoAPI{
+		TTL:          output.Data.CreationTTL,
+		CreationTime: output.Data.CreationTime,
+		CreationPath: output.Data.CreationPath,
+	}.Parse(), nil
+}
+
+//Rewrap exchanges the given wrapping token for a new one wrapping the same
+// response with the same TTL, invalidating the given token. This can be used
+// to refresh a long-lived wrapping token before it expires.
+func (v *Client) Rewrap(token string) (*WrapInfo, error) {
+	return v.RewrapContext(context.Background(), token)
+}
+
+//RewrapContext is Rewrap with a context governing the request.
+func (v *Client) RewrapContext(ctx context.Context, token string) (*WrapInfo, error) {
+	output := struct {
+		WrapInfo *wrapInfoAPI `json:"wrap_info"`
+	}{}
+
+	err := v.doRequest(withoutWrapping(ctx), "PUT", "/sys/wrapping/rewrap", struct {
+		Token string `json:"token"`
+	}{Token: token}, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	if output.WrapInfo == nil {
+		return nil, fmt.Errorf("Vault did not return a wrapped response")
+	}
+
+	return output.WrapInfo.Parse(), nil
+}
+
+//WrapData wraps the given data, which must marshal into a JSON hash, in a new
+// wrapping token with the given TTL. Unwrapping the token with UnwrapGet
+// returns the data.
+func (v *Client) WrapData(data interface{}, ttl time.Duration) (*WrapInfo, error) {
+	return v.WrapDataContext(context.Background(), data, ttl)
+}
+
+//WrapDataContext is WrapData with a context governing the request.
+func (v *Client) WrapDataContext(ctx context.Context, data interface{}, ttl time.Duration) (*WrapInfo, error) {
+	return v.RequestWrapped(ctx, ttl, func(ctx context.Context) error {
+		return v.doRequest(ctx, "PUT", "/sys/wrapping/wrap", data, nil)
+	})
+}
@@ -0,0 +1,159 @@
+package vaultkv_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/cloudfoundry-community/vaultkv"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Response wrapping", func() {
+	var wrapInfo *vaultkv.WrapInfo
+	var secret = map[string]string{"password": "hunter2"}
+
+	BeforeEach(func() {
+		InitAndUnsealVault()
+		err = vault.Set("secret/foo", secret)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Wrapping a KV read", func() {
+		var output map[string]string
+		JustBeforeEach(func() {
+			output = map[string]string{}
+			wrapInfo, err = vault.RequestWrapped(context.Background(), 5*time.Minute, func(ctx context.Context) error {
+				_, err := vault.NewKV().GetContext(ctx, "secret/foo", &output, nil)
+				return err
+			})
+		})
+
+		It("should return a wrapping token instead of the secret", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrapInfo).NotTo(BeNil())
+			Expect(wrapInfo.Token).NotTo(BeEmpty())
+			Expect(wrapInfo.TTL).To(Equal(5 * time.Minute))
+			Expect(wrapInfo.CreationPath).To(Equal("secret/foo"))
+			Expect(output).To(BeEmpty())
+		})
+
+		Describe("WrapLookup", func() {
+			var lookup *vaultkv.WrapInfo
+			JustBeforeEach(func() {
+				lookup, err = vault.WrapLookup(wrapInfo.Token)
+			})
+
+			It("should describe the wrapping token", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lookup.CreationPath).To(Equal("secret/foo"))
+				Expect(lookup.TTL).To(Equal(5 * time.Minute))
+			})
+		})
+
+		Describe("UnwrapGet", func() {
+			var unwrapped map[string]string
+			JustBeforeEach(func() {
+				unwrapped = map[string]string{}
+				err = vault.UnwrapGet(wrapInfo.Token, &unwrapped)
+			})
+
+			It("should return the secret", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unwrapped).To(Equal(secret))
+			})
+
+			It("should not be possible to unwrap the token again", func() {
+				err = vault.UnwrapGet(wrapInfo.Token, &unwrapped)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Describe("Rewrap", func() {
+			var rewrapped *vaultkv.WrapInfo
+			JustBeforeEach(func() {
+				rewrapped, err = vault.Rewrap(wrapInfo.Token)
+			})
+
+			It("should return a new token wrapping the same secret", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rewrapped.Token).NotTo(Equal(wrapInfo.Token))
+
+				By("invalidating the old token")
+				err = vault.UnwrapGet(wrapInfo.Token, nil)
+				Expect(err).To(HaveOccurred())
+
+				By("unwrapping the new token")
+				unwrapped := map[string]string{}
+				err = vault.UnwrapGet(rewrapped.Token, &unwrapped)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unwrapped).To(Equal(secret))
+			})
+		})
+	})
+
+	Describe("Wrapping a KV v2 read", func() {
+		BeforeEach(func() {
+			err = vault.UpgradeKVToV2("secret")
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(func() error {
+				_, err := vault.V2Set("secret", "bar", secret, nil)
+				return err
+			}, 10*time.Second).Should(Succeed())
+		})
+
+		It("should unwrap to the secret and its version", func() {
+			wrapInfo, err = vault.RequestWrapped(context.Background(), time.Minute, func(ctx context.Context) error {
+				_, err := vault.V2GetContext(ctx, "secret", "bar", nil, nil)
+				return err
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			unwrapped := map[string]string{}
+			var meta vaultkv.V2Version
+			meta, err = vault.UnwrapV2Get(wrapInfo.Token, &unwrapped)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwrapped).To(Equal(secret))
+			Expect(meta.Version).To(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("Making a request after the wrapped one", func() {
+		var output map[string]string
+		JustBeforeEach(func() {
+			output = map[string]string{}
+			wrapInfo, err = vault.RequestWrapped(context.Background(), time.Minute, func(ctx context.Context) error {
+				//The first response is wrapped, so it errs instead of giving output
+				_ = vault.GetContext(ctx, "secret/foo", nil)
+				return vault.GetContext(ctx, "secret/foo", &output)
+			})
+		})
+
+		It("should only wrap the first response", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(wrapInfo.CreationPath).To(Equal("secret/foo"))
+			Expect(output).To(Equal(secret))
+		})
+	})
+
+	Describe("WrapData", func() {
+		JustBeforeEach(func() {
+			wrapInfo, err = vault.WrapData(map[string]string{"beep": "boop"}, time.Minute)
+		})
+
+		It("should wrap the data", func() {
+			Expect(err).NotTo(HaveOccurred())
+			unwrapped := map[string]string{}
+			err = vault.UnwrapGet(wrapInfo.Token, &unwrapped)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unwrapped).To(Equal(map[string]string{"beep": "boop"}))
+		})
+	})
+
+	When("the wrap TTL is less than a second", func() {
+		It("should err", func() {
+			_, err = vault.WrapData(secret, time.Millisecond)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})